@@ -8,7 +8,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 var (
@@ -40,12 +39,27 @@ func Init() error {
 		}
 	}
 
-	switch repo.(type) {
+	switch r := repo.(type) {
 	case *mysqlRepo:
 		log.Println("auth: using MySQL repository")
+		gameStore = NewMySQLGameStore(r.db)
+		ratingStore = NewMySQLRatingStore(r.db)
+		sessionStore = NewMySQLSessionStore(r.db)
+		resetStore = NewMySQLResetStore(r.db)
+		verifyStore = NewMySQLVerifyStore(r.db)
+		twoFactorStore = NewMySQLTwoFactorStore(r.db)
 	default:
 		log.Println("auth: using memory repository")
+		gameStore = NewMemoryGameStore()
+		ratingStore = NewMemoryRatingStore()
+		sessionStore = NewMemorySessionStore()
+		resetStore = NewMemoryResetStore()
+		verifyStore = NewMemoryVerifyStore()
+		twoFactorStore = NewMemoryTwoFactorStore()
 	}
+	ratingSystem = RatingSystemFromEnv()
+	initSessions()
+	mailer = MailerFromEnv()
 
 	// Chargement global de tous les templates *.gohtml
 	tpl, err = template.
@@ -59,6 +73,10 @@ func Init() error {
 				}
 				return arr
 			},
+			"json": func(v any) (string, error) { // pour layout.gohtml
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
 		}).
 		ParseGlob(filepath.Join("templates", "*.gohtml"))
 
@@ -71,6 +89,14 @@ func RegisterRoutes() {
 	http.HandleFunc("/register", RegisterHandler)
 	http.HandleFunc("/home", HomeHandler) // tu peux le garder ou plus l'utiliser
 	http.HandleFunc("/logout", LogoutHandler)
+	http.HandleFunc("/forgot_password", ForgotPasswordHandler) // défini dans reset.go
+	http.HandleFunc("/reset_password", ResetPasswordHandler)
+	http.HandleFunc("/verify_email", VerifyEmailHandler) // défini dans verify.go
+	http.HandleFunc("/resend_verification", ResendVerificationHandler)
+	http.HandleFunc("/login_two_factor", LoginTwoFactorHandler) // défini dans two_factor.go
+	http.HandleFunc("/two_factor_setup", TwoFactorSetupHandler)
+	http.HandleFunc("/two_factor_enable", TwoFactorEnableHandler)
+	http.HandleFunc("/two_factor_disable", TwoFactorDisableHandler)
 
 	http.HandleFunc("/legacy", LegacyIndexHandler)      // défini dans legacy.go
 	http.HandleFunc("/profile", ProfileHandler)         // défini dans profile.go
@@ -166,6 +192,25 @@ func DBCheckHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// formPage est passé aux templates des formulaires de compte (login,
+// register, mot de passe oublié…)
+type formPage struct {
+	Error string
+	Info  string // message de confirmation
+	Token string // jeton reçu par email (reset_password.gohtml)
+	CSRF  string
+}
+
+// renderForm affiche le formulaire name avec un éventuel message d'erreur.
+func renderForm(w http.ResponseWriter, r *http.Request, name, msg string) {
+	_ = tpl.ExecuteTemplate(w, name, formPage{Error: msg, CSRF: CSRFToken(w, r)})
+}
+
+// renderInfo affiche le formulaire name avec un message de confirmation.
+func renderInfo(w http.ResponseWriter, r *http.Request, name, msg string) {
+	_ = tpl.ExecuteTemplate(w, name, formPage{Info: msg, CSRF: CSRFToken(w, r)})
+}
+
 // RegisterHandler : GET = formulaire / POST = création + auto-login
 func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -173,36 +218,51 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		_ = r.ParseForm()
 		username := strings.TrimSpace(r.FormValue("username"))
 		password := r.FormValue("password")
-		email := r.FormValue("email")
+		email := strings.TrimSpace(r.FormValue("email"))
 
 		if username == "" || password == "" {
-			_ = tpl.ExecuteTemplate(w, "register.gohtml", "Pseudo et mot de passe requis.")
+			renderForm(w, r, "register.gohtml", "Pseudo et mot de passe requis.")
 			return
 		}
 
-		if _, err := repo.CreateUser(r.Context(), username, email, password); err != nil {
+		if email != "" {
+			if err := claimEmail(r.Context(), email, 0); err != nil {
+				log.Printf("register: email check for user '%s': %v", username, err)
+				renderForm(w, r, "register.gohtml", "Adresse email déjà utilisée par un autre compte.")
+				return
+			}
+		}
+
+		u, err := repo.CreateUser(r.Context(), username, email, password)
+		if err != nil {
 			log.Printf("register error for user '%s': %v", username, err)
 			msg := "Nom d'utilisateur déjà pris ou erreur. (" + err.Error() + ")"
-			_ = tpl.ExecuteTemplate(w, "register.gohtml", msg)
+			renderForm(w, r, "register.gohtml", msg)
 			return
 		}
 
-		// cookie user + redirection vers /legacy (ton vrai menu)
-		http.SetCookie(w, &http.Cookie{
-			Name:     "user",
-			Value:    username,
-			Path:     "/",
-			HttpOnly: true,
-			Expires:  time.Now().Add(30 * 24 * time.Hour),
-		})
+		// lien de vérification (parties classées réservées aux adresses vérifiées)
+		if email != "" {
+			if err := sendVerification(r.Context(), u); err != nil {
+				log.Printf("register: send verification to '%s': %v", username, err)
+			}
+		}
+
+		// session + redirection vers /legacy (ton vrai menu)
+		if err := startSession(w, r, u); err != nil {
+			log.Printf("register: start session for '%s': %v", username, err)
+			http.Error(w, "session error", http.StatusInternalServerError)
+			return
+		}
 		http.Redirect(w, r, "/legacy", http.StatusSeeOther)
 
 	default:
-		_ = tpl.ExecuteTemplate(w, "register.gohtml", nil)
+		renderForm(w, r, "register.gohtml", "")
 	}
 }
 
 // LoginHandler : GET = formulaire / POST = vérif + cookie + redirect /legacy
+// (ou /login_two_factor si le compte a activé la double authentification)
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
@@ -211,7 +271,7 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		password := r.FormValue("password")
 
 		if username == "" || password == "" {
-			_ = tpl.ExecuteTemplate(w, "login.gohtml", "Pseudo et mot de passe requis.")
+			renderForm(w, r, "login.gohtml", "Pseudo et mot de passe requis.")
 			return
 		}
 
@@ -222,22 +282,37 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		if u == nil {
 			log.Printf("authenticate: user not found for '%s'", username)
-			_ = tpl.ExecuteTemplate(w, "login.gohtml", "Nom d'utilisateur inconnu ou mot de passe incorrect.")
+			renderForm(w, r, "login.gohtml", "Nom d'utilisateur inconnu ou mot de passe incorrect.")
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "user",
-			Value:    username,
-			Path:     "/",
-			HttpOnly: true,
-			Expires:  time.Now().Add(30 * 24 * time.Hour),
-		})
+		// double authentification : pas de session avant le second facteur
+		on, err := twoFactorEnabled(r.Context(), u.ID)
+		if err != nil {
+			log.Printf("login: two-factor lookup for '%s': %v", username, err)
+			http.Error(w, "two-factor lookup error", http.StatusInternalServerError)
+			return
+		}
+		if on {
+			if err := startLoginChallenge(w, r, u); err != nil {
+				log.Printf("login: two-factor challenge for '%s': %v", username, err)
+				http.Error(w, "session error", http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, "/login_two_factor", http.StatusSeeOther)
+			return
+		}
+
+		if err := startSession(w, r, u); err != nil {
+			log.Printf("login: start session for '%s': %v", username, err)
+			http.Error(w, "session error", http.StatusInternalServerError)
+			return
+		}
 		// Redirection vers ton vrai menu
 		http.Redirect(w, r, "/legacy", http.StatusSeeOther)
 
 	default:
-		_ = tpl.ExecuteTemplate(w, "login.gohtml", nil)
+		renderForm(w, r, "login.gohtml", "")
 	}
 }
 
@@ -251,23 +326,24 @@ func HomeHandler(w http.ResponseWriter, r *http.Request) {
 	_ = tpl.ExecuteTemplate(w, "home.gohtml", user)
 }
 
-// LogoutHandler : efface le cookie et renvoie sur /login
+// LogoutHandler : POST = ferme la session et renvoie sur /login
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "user",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Expires:  time.Unix(0, 0),
-	})
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/legacy", http.StatusSeeOther)
+		return
+	}
+	endSession(w, r)
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
-// currentUser : récupère le nom d'utilisateur depuis le cookie
+// CurrentUser renvoie le joueur connecté ("" si anonyme). Utilisé par le
+// serveur de jeu pour attribuer les parties.
+func CurrentUser(r *http.Request) string { return currentUser(r) }
+
+// currentUser : récupère le nom d'utilisateur depuis la session (voir session.go)
 func currentUser(r *http.Request) string {
-	c, err := r.Cookie("user")
-	if err != nil || c == nil {
-		return ""
+	if s := sessionFromRequest(r); s != nil {
+		return s.Username
 	}
-	return c.Value
+	return ""
 }
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testPages : formulaires réduits au message affiché (les vrais templates
+// vivent dans ../templates et ne sont pas testés ici).
+const testPages = `
+{{define "login.gohtml"}}{{.Error}}|{{.Info}}{{end}}
+{{define "register.gohtml"}}{{.Error}}|{{.Info}}{{end}}
+{{define "forgot_password.gohtml"}}{{.Error}}|{{.Info}}{{end}}
+{{define "reset_password.gohtml"}}{{.Error}}|{{.Info}}{{end}}
+{{define "login_two_factor.gohtml"}}{{.Error}}|{{.Info}}{{end}}
+`
+
+// recordingMailer garde les emails envoyés.
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+var linkToken = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken : jeton du lien du dernier email envoyé.
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	sub := linkToken.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if sub == nil {
+		t.Fatal("no link in email")
+	}
+	token, err := url.QueryUnescape(sub[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// setupAuth remplace les stores par des stores mémoire vides, comme Init
+// sans MySQL, et renvoie le mailer de test.
+func setupAuth(t *testing.T) *recordingMailer {
+	t.Helper()
+	repo = NewMemoryRepo()
+	sessionStore = NewMemorySessionStore()
+	resetStore = NewMemoryResetStore()
+	verifyStore = NewMemoryVerifyStore()
+	twoFactorStore = NewMemoryTwoFactorStore()
+	sessionKey = []byte("test-session-key")
+	tpl = template.Must(template.New("base").Parse(testPages))
+	m := &recordingMailer{}
+	mailer = m
+	return m
+}
+
+// newUser crée un compte (mot de passe "secret"), à l'email vérifié si
+// email n'est pas vide.
+func newUser(t *testing.T, name, email string) *User {
+	t.Helper()
+	u, err := repo.CreateUser(context.Background(), name, email, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "" {
+		if ok, err := repo.MarkEmailVerified(context.Background(), u.ID, email); err != nil || !ok {
+			t.Fatalf("MarkEmailVerified: %v, %v", ok, err)
+		}
+		u.EmailVerified = true
+	}
+	return u
+}
+
+// serve appelle h avec un formulaire (POST si form n'est pas nil) et les
+// cookies donnés.
+func serve(h http.HandlerFunc, target string, form url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form != nil {
+		r = httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		r = httptest.NewRequest(http.MethodGet, target, nil)
+	}
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+// sessionCookieOf : cookie de session posé par la réponse (nil si aucun).
+func sessionCookieOf(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie && c.Value != "" {
+			return c
+		}
+	}
+	return nil
+}
+
+// login connecte name avec le mot de passe password, en présentant
+// cookies, et renvoie le cookie de session.
+func login(t *testing.T, name, password string, cookies ...*http.Cookie) *http.Cookie {
+	t.Helper()
+	w := serve(LoginHandler, "/login", url.Values{"username": {name}, "password": {password}}, cookies)
+	c := sessionCookieOf(w)
+	if c == nil {
+		t.Fatalf("login %s: no session cookie (status %d, body %q)", name, w.Code, w.Body.String())
+	}
+	return c
+}
+
+// userOf : joueur connecté avec ce cookie ("" si la session est invalide).
+func userOf(c *http.Cookie) string {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(c)
+	return currentUser(r)
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"net/http"
+)
+
+// Protection CSRF par jeton synchronisé : le jeton est le HMAC (clé des
+// sessions) de la session de connexion, ou, pour un visiteur anonyme
+// (formulaires de connexion et d'inscription), d'un cookie aléatoire
+// "csrf". Toute requête qui n'est ni GET, ni HEAD, ni OPTIONS doit le
+// renvoyer dans le champ csrf_token ou l'en-tête X-CSRF-Token.
+const (
+	CSRFField  = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+	csrfCookie = "csrf"
+)
+
+// maxFormBytes : taille maximale d'un corps de requête lu pour y chercher
+// le jeton (les formulaires du site sont tous bien plus petits).
+const maxFormBytes = 64 << 10
+
+// csrfBinding : valeur à laquelle le jeton est lié ("" si aucune). Avec
+// create, un cookie anonyme est posé au besoin.
+func csrfBinding(w http.ResponseWriter, r *http.Request, create bool) string {
+	if s := sessionFromRequest(r); s != nil {
+		return "session:" + s.TokenHash
+	}
+	if c, err := r.Cookie(csrfCookie); err == nil && c.Value != "" {
+		return "anon:" + c.Value
+	}
+	if !create {
+		return ""
+	}
+	v, err := randomToken()
+	if err != nil {
+		log.Printf("auth: csrf cookie: %v", err)
+		return ""
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    v,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return "anon:" + v
+}
+
+func csrfMAC(binding string) string {
+	mac := hmac.New(sha256.New, sessionKey)
+	mac.Write([]byte("csrf|" + binding))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CSRFToken renvoie le jeton à placer dans les formulaires de la page. À
+// appeler avant d'écrire la réponse (il peut poser un cookie).
+func CSRFToken(w http.ResponseWriter, r *http.Request) string {
+	b := csrfBinding(w, r, true)
+	if b == "" {
+		return ""
+	}
+	return csrfMAC(b)
+}
+
+// ValidCSRF vérifie le jeton de la requête (en-tête ou champ de formulaire).
+func ValidCSRF(r *http.Request) bool {
+	b := csrfBinding(nil, r, false)
+	if b == "" {
+		return false
+	}
+	token := r.Header.Get(CSRFHeader)
+	if token == "" {
+		token = r.PostFormValue(CSRFField)
+	}
+	return token != "" && hmac.Equal([]byte(token), []byte(csrfMAC(b)))
+}
+
+// RequireCSRF refuse (403) les requêtes modifiantes sans jeton valide.
+func RequireCSRF(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			r.Body = http.MaxBytesReader(w, r.Body, maxFormBytes)
+			if !ValidCSRF(r) {
+				http.Error(w, "invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
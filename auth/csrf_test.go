@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// csrfRequest : requête method vers un handler protégé, avec cookies, et
+// token dans le champ de formulaire (ou dans l'en-tête si header).
+func csrfRequest(method, token string, header bool, cookies []*http.Cookie) int {
+	form := url.Values{"x": {"1"}}
+	if token != "" && !header {
+		form.Set(CSRFField, token)
+	}
+	r := httptest.NewRequest(method, "/action", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" && header {
+		r.Header.Set(CSRFHeader, token)
+	}
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	RequireCSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(w, r)
+	return w.Code
+}
+
+// tokenFor : jeton CSRF de la page vue avec cookies.
+func tokenFor(cookies []*http.Cookie) string {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return CSRFToken(httptest.NewRecorder(), r)
+}
+
+func TestRequireCSRF(t *testing.T) {
+	setupAuth(t)
+	newUser(t, "alice", "")
+	newUser(t, "bob", "")
+	alice := []*http.Cookie{login(t, "alice", "secret")}
+	bob := []*http.Cookie{login(t, "bob", "secret")}
+	token := tokenFor(alice)
+
+	tests := []struct {
+		name    string
+		method  string
+		token   string
+		header  bool
+		cookies []*http.Cookie
+		want    int
+	}{
+		{"GET without token", http.MethodGet, "", false, alice, http.StatusNoContent},
+		{"POST without token", http.MethodPost, "", false, alice, http.StatusForbidden},
+		{"POST with form token", http.MethodPost, token, false, alice, http.StatusNoContent},
+		{"POST with header token", http.MethodPost, token, true, alice, http.StatusNoContent},
+		{"DELETE without token", http.MethodDelete, "", false, alice, http.StatusForbidden},
+		{"token of another session", http.MethodPost, token, false, bob, http.StatusForbidden},
+		{"token without session", http.MethodPost, token, false, nil, http.StatusForbidden},
+		{"tampered token", http.MethodPost, token + "x", false, alice, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := csrfRequest(tt.method, tt.token, tt.header, tt.cookies); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCSRFAnonymousCookie(t *testing.T) {
+	setupAuth(t)
+
+	// visiteur anonyme : le jeton est lié au cookie "csrf" posé avec la page
+	w := httptest.NewRecorder()
+	token := CSRFToken(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookies := w.Result().Cookies()
+	if token == "" || len(cookies) != 1 || cookies[0].Name != csrfCookie {
+		t.Fatalf("anonymous token %q, cookies %v", token, cookies)
+	}
+	if got := csrfRequest(http.MethodPost, token, false, cookies); got != http.StatusNoContent {
+		t.Errorf("anonymous POST with token: status %d", got)
+	}
+	other := []*http.Cookie{{Name: csrfCookie, Value: "someone-else"}}
+	if got := csrfRequest(http.MethodPost, token, false, other); got != http.StatusForbidden {
+		t.Errorf("anonymous POST with another visitor's cookie: status %d", got)
+	}
+
+	// à la connexion, le jeton anonyme ne vaut plus
+	newUser(t, "alice", "")
+	session := login(t, "alice", "secret", cookies...)
+	if got := csrfRequest(http.MethodPost, token, false, append(cookies, session)); got != http.StatusForbidden {
+		t.Errorf("anonymous token accepted with a session: status %d", got)
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Statuts d'une partie (enum games.status)
+const (
+	GamePending   = "pending"
+	GameActive    = "active"
+	GameFinished  = "finished"
+	GameAbandoned = "abandoned"
+)
+
+// Issue d'une partie terminée (enum games.result). winner_id ne suffit pas :
+// il est NULL pour un nul, mais aussi quand le gagnant n'a pas de compte
+// (deuxième joueur en hot-seat) ou l'a supprimé.
+const (
+	ResultP1   = "p1"
+	ResultP2   = "p2"
+	ResultDraw = "draw"
+)
+
+// Visibilité d'une partie (enum games.privacy)
+const (
+	PrivacyPublic  = "public"
+	PrivacyPrivate = "private"
+)
+
+// GameRecord : une ligne de la table games. Les IDs joueurs valent 0 quand
+// la place n'est pas tenue par un compte (NULL en base).
+type GameRecord struct {
+	ID         int64
+	Status     string
+	Player1ID  int
+	Player2ID  int
+	WinnerID   int
+	Result     string // ResultP1 | ResultP2 | ResultDraw ("" si non terminée)
+	Rows       int
+	Cols       int
+	ConnectN   int
+	Privacy    string // "public" | "private"
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// MoveRecord : une ligne de la table moves.
+type MoveRecord struct {
+	MoveNo   int // à partir de 1
+	PlayerID int
+	Col, Row int
+	Color    string // "R" (joueur 1) | "Y" (joueur 2)
+	PlayedAt time.Time
+}
+
+// GameStats : bilan des parties terminées d'un joueur (les parties en cours
+// ou abandonnées ne comptent pas).
+type GameStats struct {
+	Played, Wins, Losses, Draws int
+}
+
+// GameStore is the persistence abstraction for games and their moves.
+type GameStore interface {
+	// CreateGame inserts a game and sets rec.ID.
+	CreateGame(ctx context.Context, rec *GameRecord) error
+	// SetPlayer2 seats a second account in the game.
+	SetPlayer2(ctx context.Context, gameID int64, userID int) error
+	// RecordMove appends a move; the first move marks the game active.
+	RecordMove(ctx context.Context, gameID int64, m MoveRecord) error
+	// TruncateMoves keeps only the first keep moves (undo).
+	TruncateMoves(ctx context.Context, gameID int64, keep int) error
+	// UpdateStatus sets the status and result ("" = none) of a game; the
+	// winner is the account in the winning seat, if any.
+	UpdateStatus(ctx context.Context, gameID int64, status, result string) error
+	GetGame(ctx context.Context, gameID int64) (*GameRecord, error)
+	// ListOpenGames returns pending public games with a free P2 seat, newest first.
+	ListOpenGames(ctx context.Context, limit int) ([]GameRecord, error)
+	ListMoves(ctx context.Context, gameID int64) ([]MoveRecord, error)
+	// Stats counts the finished games of a user and their outcomes.
+	Stats(ctx context.Context, userID int) (GameStats, error)
+}
+
+// gameStore : implémentation choisie dans Init (MySQL si dispo, sinon mémoire)
+var gameStore GameStore
+
+// Games renvoie le GameStore configuré par Init.
+func Games() GameStore { return gameStore }
+
+// UserID renvoie l'ID du compte username (0 si inconnu).
+func UserID(ctx context.Context, username string) int {
+	if repo == nil || username == "" {
+		return 0
+	}
+	u, err := repo.GetByUsername(ctx, username)
+	if err != nil || u == nil {
+		return 0
+	}
+	return u.ID
+}
+
+// Username renvoie le nom du compte id ("" si inconnu).
+func Username(ctx context.Context, id int) string {
+	if repo == nil || id == 0 {
+		return ""
+	}
+	u, err := repo.GetByID(ctx, id)
+	if err != nil || u == nil {
+		return ""
+	}
+	return u.Username
+}
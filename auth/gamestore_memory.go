@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryGameStore struct {
+	mu     sync.RWMutex
+	games  map[int64]*memoryGame
+	nextID int64
+}
+
+type memoryGame struct {
+	rec   GameRecord
+	moves []MoveRecord
+}
+
+func NewMemoryGameStore() GameStore {
+	return &memoryGameStore{games: make(map[int64]*memoryGame), nextID: 1}
+}
+
+func (m *memoryGameStore) CreateGame(ctx context.Context, rec *GameRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec.ID = m.nextID
+	m.nextID++
+	if rec.Status == "" {
+		rec.Status = GamePending
+	}
+	if rec.Privacy == "" {
+		rec.Privacy = PrivacyPublic
+	}
+	rec.CreatedAt = time.Now()
+	m.games[rec.ID] = &memoryGame{rec: *rec}
+	return nil
+}
+
+func (m *memoryGameStore) SetPlayer2(ctx context.Context, gameID int64, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mg, ok := m.games[gameID]
+	if !ok {
+		return errors.New("not found")
+	}
+	mg.rec.Player2ID = userID
+	return nil
+}
+
+func (m *memoryGameStore) RecordMove(ctx context.Context, gameID int64, mv MoveRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mg, ok := m.games[gameID]
+	if !ok {
+		return errors.New("not found")
+	}
+	if mv.MoveNo != len(mg.moves)+1 {
+		return errors.New("duplicate or missing move number")
+	}
+	mv.PlayedAt = time.Now()
+	mg.moves = append(mg.moves, mv)
+	if mg.rec.Status == GamePending {
+		mg.rec.Status = GameActive
+		mg.rec.StartedAt = mv.PlayedAt
+	}
+	return nil
+}
+
+func (m *memoryGameStore) TruncateMoves(ctx context.Context, gameID int64, keep int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mg, ok := m.games[gameID]
+	if !ok {
+		return errors.New("not found")
+	}
+	if keep < len(mg.moves) {
+		mg.moves = mg.moves[:keep]
+	}
+	return nil
+}
+
+func (m *memoryGameStore) UpdateStatus(ctx context.Context, gameID int64, status, result string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mg, ok := m.games[gameID]
+	if !ok {
+		return errors.New("not found")
+	}
+	mg.rec.Status = status
+	mg.rec.Result = result
+	switch result {
+	case ResultP1:
+		mg.rec.WinnerID = mg.rec.Player1ID
+	case ResultP2:
+		mg.rec.WinnerID = mg.rec.Player2ID
+	default:
+		mg.rec.WinnerID = 0
+	}
+	switch status {
+	case GameFinished, GameAbandoned:
+		mg.rec.FinishedAt = time.Now()
+	default:
+		mg.rec.FinishedAt = time.Time{}
+	}
+	return nil
+}
+
+func (m *memoryGameStore) GetGame(ctx context.Context, gameID int64) (*GameRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mg, ok := m.games[gameID]
+	if !ok {
+		return nil, nil
+	}
+	rec := mg.rec
+	return &rec, nil
+}
+
+func (m *memoryGameStore) ListOpenGames(ctx context.Context, limit int) ([]GameRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []GameRecord
+	for _, mg := range m.games {
+		if mg.rec.Status == GamePending && mg.rec.Privacy == PrivacyPublic && mg.rec.Player2ID == 0 {
+			out = append(out, mg.rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memoryGameStore) ListMoves(ctx context.Context, gameID int64) ([]MoveRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mg, ok := m.games[gameID]
+	if !ok {
+		return nil, nil
+	}
+	return append([]MoveRecord(nil), mg.moves...), nil
+}
+
+func (m *memoryGameStore) Stats(ctx context.Context, userID int) (GameStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var st GameStats
+	for _, mg := range m.games {
+		rec := mg.rec
+		if userID == 0 || (rec.Player1ID != userID && rec.Player2ID != userID) {
+			continue
+		}
+		if rec.Status != GameFinished {
+			continue
+		}
+		st.Played++
+		mine := ResultP1
+		if rec.Player1ID != userID {
+			mine = ResultP2
+		}
+		switch rec.Result {
+		case ResultDraw:
+			st.Draws++
+		case mine:
+			st.Wins++
+		default:
+			st.Losses++
+		}
+	}
+	return st, nil
+}
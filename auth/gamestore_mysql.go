@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type mysqlGameStore struct {
+	db *sql.DB
+}
+
+// NewMySQLGameStore wraps the connection of a MySQL repository (tables games
+// and moves of database/power4.sql).
+func NewMySQLGameStore(db *sql.DB) GameStore {
+	return &mysqlGameStore{db: db}
+}
+
+// nullID : 0 → NULL pour les clés étrangères optionnelles.
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+func (m *mysqlGameStore) CreateGame(ctx context.Context, rec *GameRecord) error {
+	if rec.Status == "" {
+		rec.Status = GamePending
+	}
+	if rec.Privacy == "" {
+		rec.Privacy = PrivacyPublic
+	}
+	res, err := m.db.ExecContext(ctx, `
+		INSERT INTO games (status, player1_id, player2_id, rows_count, cols_count, connect_n, privacy, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NOW())`,
+		rec.Status, rec.Player1ID, nullID(rec.Player2ID), rec.Rows, rec.Cols, rec.ConnectN, rec.Privacy,
+	)
+	if err != nil {
+		return err
+	}
+	rec.ID, err = res.LastInsertId()
+	rec.CreatedAt = time.Now()
+	return err
+}
+
+func (m *mysqlGameStore) SetPlayer2(ctx context.Context, gameID int64, userID int) error {
+	_, err := m.db.ExecContext(ctx, "UPDATE games SET player2_id = ? WHERE id = ?", nullID(userID), gameID)
+	return err
+}
+
+// RecordMove insère le coup et passe la partie en 'active' au premier coup,
+// dans une même transaction.
+func (m *mysqlGameStore) RecordMove(ctx context.Context, gameID int64, mv MoveRecord) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO moves (game_id, move_no, player_id, column_index, row_index, disc_color, played_at)
+		VALUES (?, ?, ?, ?, ?, ?, NOW())`,
+		gameID, mv.MoveNo, mv.PlayerID, mv.Col, mv.Row, mv.Color,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE games SET status = 'active', started_at = NOW() WHERE id = ? AND status = 'pending'",
+		gameID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *mysqlGameStore) TruncateMoves(ctx context.Context, gameID int64, keep int) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM moves WHERE game_id = ? AND move_no > ?", gameID, keep)
+	return err
+}
+
+// UpdateStatus : winner_id est déduit de result et des places.
+func (m *mysqlGameStore) UpdateStatus(ctx context.Context, gameID int64, status, result string) error {
+	finished := "NULL"
+	if status == GameFinished || status == GameAbandoned {
+		finished = "NOW()"
+	}
+	_, err := m.db.ExecContext(ctx, `
+		UPDATE games SET status = ?, result = ?,
+		       winner_id = CASE ? WHEN 'p1' THEN player1_id WHEN 'p2' THEN player2_id END,
+		       finished_at = `+finished+`
+		WHERE id = ?`,
+		status, sql.NullString{String: result, Valid: result != ""}, result, gameID)
+	return err
+}
+
+func (m *mysqlGameStore) GetGame(ctx context.Context, gameID int64) (*GameRecord, error) {
+	row := m.db.QueryRowContext(ctx, `
+		SELECT id, status, player1_id, player2_id, winner_id, result, rows_count, cols_count, connect_n,
+		       privacy, created_at, started_at, finished_at
+		FROM games WHERE id = ?`, gameID)
+
+	var (
+		rec               GameRecord
+		p1, p2, winner    sql.NullInt64
+		result            sql.NullString
+		started, finished sql.NullTime
+	)
+	if err := row.Scan(&rec.ID, &rec.Status, &p1, &p2, &winner, &result, &rec.Rows, &rec.Cols,
+		&rec.ConnectN, &rec.Privacy, &rec.CreatedAt, &started, &finished); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rec.Player1ID = int(p1.Int64) // 0 : compte supprimé
+	rec.Player2ID = int(p2.Int64)
+	rec.WinnerID = int(winner.Int64)
+	rec.Result = result.String
+	rec.StartedAt = started.Time
+	rec.FinishedAt = finished.Time
+	return &rec, nil
+}
+
+func (m *mysqlGameStore) ListOpenGames(ctx context.Context, limit int) ([]GameRecord, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, status, player1_id, rows_count, cols_count, connect_n, privacy, created_at
+		FROM games
+		WHERE status = 'pending' AND privacy = 'public' AND player2_id IS NULL AND player1_id IS NOT NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []GameRecord
+	for rows.Next() {
+		var rec GameRecord
+		if err := rows.Scan(&rec.ID, &rec.Status, &rec.Player1ID, &rec.Rows, &rec.Cols, &rec.ConnectN,
+			&rec.Privacy, &rec.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (m *mysqlGameStore) ListMoves(ctx context.Context, gameID int64) ([]MoveRecord, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT move_no, player_id, column_index, row_index, disc_color, played_at
+		FROM moves WHERE game_id = ? ORDER BY move_no`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MoveRecord
+	for rows.Next() {
+		var (
+			mv     MoveRecord
+			player sql.NullInt64
+		)
+		if err := rows.Scan(&mv.MoveNo, &player, &mv.Col, &mv.Row, &mv.Color, &mv.PlayedAt); err != nil {
+			return nil, err
+		}
+		mv.PlayerID = int(player.Int64) // 0 : compte supprimé
+		out = append(out, mv)
+	}
+	return out, rows.Err()
+}
+
+func (m *mysqlGameStore) Stats(ctx context.Context, userID int) (GameStats, error) {
+	var (
+		st                      GameStats
+		gp, wins, losses, draws sql.NullInt64
+	)
+	err := m.db.QueryRowContext(ctx, `
+		SELECT
+			SUM(CASE WHEN status='finished' THEN 1 ELSE 0 END) AS gp,
+			SUM(CASE WHEN status='finished' AND result = IF(player1_id = ?, 'p1', 'p2') THEN 1 ELSE 0 END) AS wins,
+			SUM(CASE WHEN status='finished' AND result = IF(player1_id = ?, 'p2', 'p1') THEN 1 ELSE 0 END) AS losses,
+			SUM(CASE WHEN status='finished' AND result = 'draw' THEN 1 ELSE 0 END) AS draws
+		FROM games
+		WHERE player1_id = ? OR player2_id = ?`,
+		userID, userID, userID, userID,
+	).Scan(&gp, &wins, &losses, &draws)
+	if err != nil && err != sql.ErrNoRows {
+		return st, err
+	}
+	st.Played = int(gp.Int64)
+	st.Wins = int(wins.Int64)
+	st.Losses = int(losses.Int64)
+	st.Draws = int(draws.Int64)
+	return st, nil
+}
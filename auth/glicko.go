@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Valeurs initiales Glicko-2 d'un joueur jamais classé
+const (
+	DefaultRD         = 350.0
+	DefaultVolatility = 0.06
+)
+
+// glickoScale : facteur de conversion entre l'échelle Glicko et Glicko-2
+const glickoScale = 173.7178
+
+// Glicko2 : système Glicko-2 (Glickman). Chaque partie est traitée comme une
+// période de notation ; l'incertitude (RD) d'un joueur inactif croît d'une
+// période par Period écoulée depuis sa dernière partie classée.
+type Glicko2 struct {
+	Tau           float64       // contrainte sur l'évolution de la volatilité
+	Period        time.Duration // durée d'une période d'inactivité
+	ProvisionalRD float64       // au-delà, le classement est jugé provisoire
+}
+
+// DefaultGlicko2 : τ=0.5, période d'une semaine, provisoire au-dessus de 110 de RD.
+var DefaultGlicko2 = Glicko2{Tau: 0.5, Period: 7 * 24 * time.Hour, ProvisionalRD: 110}
+
+// Glicko2FromEnv lit GLICKO_TAU, GLICKO_PERIOD (ex. "168h") et GLICKO_PROVISIONAL_RD.
+func Glicko2FromEnv() Glicko2 {
+	g := DefaultGlicko2
+	if f, err := strconv.ParseFloat(os.Getenv("GLICKO_TAU"), 64); err == nil && f > 0 {
+		g.Tau = f
+	}
+	if d, err := time.ParseDuration(os.Getenv("GLICKO_PERIOD")); err == nil && d > 0 {
+		g.Period = d
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("GLICKO_PROVISIONAL_RD"), 64); err == nil && f > 0 {
+		g.ProvisionalRD = f
+	}
+	return g
+}
+
+// currentRD : RD de r à l'instant now, augmentée des périodes d'inactivité.
+func (g Glicko2) currentRD(r Rating, now time.Time) float64 {
+	rd, vol := r.RD, r.Volatility
+	if rd <= 0 {
+		rd = DefaultRD
+	}
+	if vol <= 0 {
+		vol = DefaultVolatility
+	}
+	if r.UpdatedAt.IsZero() || g.Period <= 0 {
+		return rd
+	}
+	periods := math.Floor(now.Sub(r.UpdatedAt).Hours() / g.Period.Hours())
+	if periods <= 0 {
+		return rd
+	}
+	phi := rd / glickoScale
+	phi = math.Sqrt(phi*phi + periods*vol*vol)
+	return math.Min(phi*glickoScale, DefaultRD)
+}
+
+// Rate applique une période Glicko-2 à chacun des deux joueurs.
+func (g Glicko2) Rate(a, b Rating, scoreA float64) (Rating, Rating) {
+	now := time.Now()
+	rdA, rdB := g.currentRD(a, now), g.currentRD(b, now)
+	na := g.update(a, rdA, b, rdB, scoreA, now)
+	nb := g.update(b, rdB, a, rdA, 1-scoreA, now)
+	return na, nb
+}
+
+func (g Glicko2) update(p Rating, rd float64, o Rating, ord float64, s float64, now time.Time) Rating {
+	mu := float64(p.Rating-1500) / glickoScale
+	phi := rd / glickoScale
+	sigma := p.Volatility
+	if sigma <= 0 {
+		sigma = DefaultVolatility
+	}
+	muJ := float64(o.Rating-1500) / glickoScale
+	phiJ := ord / glickoScale
+
+	gJ := 1 / math.Sqrt(1+3*phiJ*phiJ/(math.Pi*math.Pi))
+	e := 1 / (1 + math.Exp(-gJ*(mu-muJ)))
+	v := 1 / (gJ * gJ * e * (1 - e))
+	delta := v * gJ * (s - e)
+
+	sigma = g.volatility(phi, sigma, v, delta)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phiNew := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	muNew := mu + phiNew*phiNew*gJ*(s-e)
+
+	out := p
+	out.Rating = int(math.Round(muNew*glickoScale + 1500))
+	out.RD = math.Min(phiNew*glickoScale, DefaultRD)
+	out.Volatility = sigma
+	out.Games++
+	out.UpdatedAt = now
+	return out
+}
+
+// volatility : nouvelle volatilité (algorithme d'Illinois, étape 5 de Glickman).
+func (g Glicko2) volatility(phi, sigma, v, delta float64) float64 {
+	const eps = 1e-6
+	tau := g.Tau
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > eps {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
+
+// Provisional : RD encore trop élevée pour un classement fiable.
+func (g Glicko2) Provisional(r Rating) bool {
+	return g.currentRD(r, time.Now()) > g.ProvisionalRD
+}
+
+// Interval : intervalle de confiance à 95 % (rating ± 2·RD).
+func (g Glicko2) Interval(r Rating) (lo, hi int) {
+	d := int(math.Round(2 * g.currentRD(r, time.Now())))
+	return r.Rating - d, r.Rating + d
+}
@@ -13,6 +13,7 @@ type LegacyIndexData struct {
 	Username string
 	ELO      int
 	GOBase   string
+	CSRF     string // jeton des formulaires (bot, déconnexion, suppression)
 }
 
 // LegacyIndexHandler renders the converted index menu.
@@ -25,26 +26,20 @@ func LegacyIndexHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// try to fetch user info from repo; ignore errors and fall back to cookie username
-	elo := 1200
+	elo := DefaultRating
 	if repo != nil {
 		if u, _ := repo.GetByUsername(context.Background(), username); u != nil {
 			username = u.Username
-			// TODO: récupérer l'ELO réel si tu ajoutes la table de rating
+			elo = RatingOf(context.Background(), u.ID)
 		}
 	}
 
 	data := LegacyIndexData{
 		Username: username,
 		ELO:      elo,
-		GOBase:   os.Getenv("GO_BASE"),
+		GOBase:   goBase(),
+		CSRF:     CSRFToken(w, r),
 	}
-
-	// default GO_BASE
-	if data.GOBase == "" {
-		data.GOBase = "http://localhost:8080"
-	}
-	// normalize GOBase: remove trailing slash to avoid // in template
-	data.GOBase = strings.TrimRight(data.GOBase, "/")
 	log.Printf("legacy: using GOBase=%s", data.GOBase)
 
 	// On utilise le tpl global déjà chargé dans Init()
@@ -54,3 +49,14 @@ func LegacyIndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// goBase : URL publique du serveur de jeu (GO_BASE, http://localhost:8080
+// par défaut), sans slash final pour éviter // dans les liens. Sert aussi
+// aux liens envoyés par email : on ne se fie pas à l'en-tête Host.
+func goBase() string {
+	base := os.Getenv("GO_BASE")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/")
+}
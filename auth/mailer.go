@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message : un email texte.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional emails (password reset, verification…).
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// mailer : implémentation choisie dans Init (voir MailerFromEnv)
+var mailer Mailer
+
+// SetMailer remplace le Mailer configuré par Init.
+func SetMailer(m Mailer) { mailer = m }
+
+// MailerFromEnv : SMTP si SMTP_HOST est défini (SMTP_PORT, SMTP_USER,
+// SMTP_PASS, MAIL_FROM), sinon FileMailer dans MAIL_DIR (log seul si vide).
+func MailerFromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		dir := os.Getenv("MAIL_DIR")
+		log.Printf("auth: SMTP_HOST not set — emails written to log (MAIL_DIR=%q)", dir)
+		return &FileMailer{Dir: dir}
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@" + host
+	}
+	log.Printf("auth: sending emails through %s:%s", host, port)
+	return &SMTPMailer{
+		Addr: net.JoinHostPort(host, port),
+		User: os.Getenv("SMTP_USER"),
+		Pass: os.Getenv("SMTP_PASS"),
+		From: from,
+	}
+}
+
+// SMTPMailer envoie par SMTP (STARTTLS si le serveur le propose, et
+// authentification PLAIN si User est renseigné).
+type SMTPMailer struct {
+	Addr       string // hôte:port
+	User, Pass string
+	From       string
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, m Message) error {
+	var auth smtp.Auth
+	if s.User != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.User, s.Pass, host)
+	}
+	msg, err := formatMessage(s.From, m)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.Addr, auth, s.From, []string{m.To}, msg)
+}
+
+// FileMailer écrit chaque message dans un fichier .eml de Dir (et le
+// journalise) : pour le développement local et les tests. Sans Dir, le
+// message est seulement journalisé.
+type FileMailer struct {
+	Dir string
+
+	mu sync.Mutex
+	n  int
+}
+
+func (f *FileMailer) Send(ctx context.Context, m Message) error {
+	msg, err := formatMessage("no-reply@localhost", m)
+	if err != nil {
+		return err
+	}
+	log.Printf("mail: to=%s subject=%q\n%s", m.To, m.Subject, m.Body)
+	if f.Dir == "" {
+		return nil
+	}
+	f.mu.Lock()
+	f.n++
+	name := fmt.Sprintf("%s-%03d.eml", time.Now().Format("20060102-150405"), f.n)
+	f.mu.Unlock()
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(f.Dir, name), msg, 0o644)
+}
+
+// formatMessage : en-têtes RFC 5322 minimaux et corps texte UTF-8. Un
+// retour à la ligne dans un en-tête (injection) est refusé.
+func formatMessage(from string, m Message) ([]byte, error) {
+	for _, h := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(h, "\r\n") {
+			return nil, errors.New("auth: newline in mail header")
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"time"
 )
 
 // PublicProfileHandler shows a public profile for any username (query param `username`).
@@ -18,7 +17,7 @@ func PublicProfileHandler(w http.ResponseWriter, r *http.Request) {
 	data := ProfileData{
 		Username: username,
 		Avatar:   pickAvatarForName(username),
-		ELO:      1200,
+		ELO:      DefaultRating,
 	}
 
 	// Enrichir avec les infos de la BDD si possible
@@ -28,6 +27,7 @@ func PublicProfileHandler(w http.ResponseWriter, r *http.Request) {
 			if u.AvatarURL != "" {
 				data.Avatar = u.AvatarURL
 			}
+			data.ELO = RatingOf(context.Background(), u.ID)
 		}
 	}
 
@@ -86,21 +86,29 @@ func ChooseAvatarHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// GET : afficher la page de choix d'avatar
-	if err := tpl.ExecuteTemplate(w, "choose_avatar.gohtml", u); err != nil {
+	data := struct {
+		*User
+		CSRF string
+	}{u, CSRFToken(w, r)}
+	if err := tpl.ExecuteTemplate(w, "choose_avatar.gohtml", data); err != nil {
 		log.Printf("choose_avatar: template error for '%s': %v", username, err)
 		http.Error(w, "template error", http.StatusInternalServerError)
 		return
 	}
 }
 
-// DeleteAccountHandler deletes the currently logged-in account.
-// ⚠️ Version "rapide" : accepte GET et POST (un simple clic sur le lien supprime le compte).
+// DeleteAccountHandler deletes the currently logged-in account (POST only,
+// CSRF token checked by RequireCSRF).
 func DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 	username := currentUser(r)
 	if username == "" {
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/legacy", http.StatusSeeOther)
+		return
+	}
 	if repo == nil {
 		http.Error(w, "repository not configured", http.StatusInternalServerError)
 		return
@@ -124,14 +132,14 @@ func DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// clear cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "user",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Expires:  time.Unix(0, 0),
-	})
+	// toutes les sessions du compte sont invalidées (pas seulement celle-ci)
+	if err := sessionStore.DeleteUserSessions(ctx, u.ID); err != nil {
+		log.Printf("delete_account: DeleteUserSessions error for '%s': %v", username, err)
+	}
+	if err := twoFactorStore.DisableTwoFactor(ctx, u.ID); err != nil {
+		log.Printf("delete_account: DisableTwoFactor error for '%s': %v", username, err)
+	}
+	endSession(w, r)
 
 	// retour vers la page de connexion
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
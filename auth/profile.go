@@ -13,18 +13,39 @@ import (
 
 // ProfileData est passé au template profile.gohtml
 type ProfileData struct {
-	Username     string
-	Email        string
-	Avatar       string
-	ELO          int
-	Rank         string
-	RankMin      int // ELO minimum du rang actuel
-	RankMax      int // ELO nécessaire pour passer au rang suivant
-	RankProgress int // Progression (0–100) vers le rang suivant
-	GamesPlayed  int
-	Wins         int
-	Losses       int
-	Draws        int
+	Username      string
+	Email         string
+	EmailVerified bool
+	Avatar        string
+	ELO           int
+	RatingLow     int  // intervalle de confiance du classement (Glicko-2)
+	RatingHigh    int  //
+	Provisional   bool // classement encore incertain (hors leaderboard)
+	Rank          string
+	RankMin       int // ELO minimum du rang actuel
+	RankMax       int // ELO nécessaire pour passer au rang suivant
+	RankProgress  int // Progression (0–100) vers le rang suivant
+	GamesPlayed   int
+	Wins          int
+	Losses        int
+	Draws         int
+	CSRF          string // jeton du formulaire de mise à jour
+	Notice        string // message après une action (email, vérification…)
+	TwoFactor     bool   // double authentification activée
+	RecoveryLeft  int    // codes de secours restants
+}
+
+// profileNotices : messages affichés selon ?notice= (jamais le texte brut
+// de l'URL)
+var profileNotices = map[string]string{
+	"email_taken":         "Cette adresse email est déjà utilisée par un autre compte.",
+	"verification_sent":   "Un lien de vérification vient d'être envoyé à ton adresse email.",
+	"resend_limited":      "Un lien a déjà été envoyé récemment. Réessaie dans quelques minutes.",
+	"email_verified":      "Adresse email vérifiée : les parties classées sont débloquées.",
+	"verify_failed":       "Lien de vérification invalide ou expiré.",
+	"two_factor_disabled": "Double authentification désactivée.",
+	"two_factor_bad_code": "Code invalide : la double authentification reste activée.",
+	"two_factor_locked":   "Trop de codes erronés. Réessaie dans quelques minutes.",
 }
 
 // ProfileHandler : affiche (GET) et met à jour (POST) le profil du joueur connecté
@@ -49,24 +70,35 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 				log.Printf("profile POST: GetByUsername error for '%s': %v", username, err)
 			}
 			if u != nil {
-				// Mise à jour de l'email en BDD (MySQL uniquement)
-				if email != "" {
-					if mr, ok := repo.(*mysqlRepo); ok {
-						if _, err := mr.db.ExecContext(ctx,
-							"UPDATE users SET email = ? WHERE id = ?",
-							email, u.ID,
-						); err != nil {
-							log.Printf("profile POST: update email error for '%s': %v", username, err)
-						}
-					}
-				}
-
 				// Mise à jour de l'avatar via le repo (MySQL + mémoire)
 				if avatar != "" {
 					if err := repo.UpdateAvatar(ctx, u.ID, avatar); err != nil {
 						log.Printf("profile POST: update avatar error for '%s': %v", username, err)
 					}
 				}
+
+				// Changement d'email : la nouvelle adresse repasse non
+				// vérifiée et reçoit un lien de vérification
+				if email != "" && email != u.Email {
+					if err := claimEmail(ctx, email, u.ID); err != nil {
+						if err != errEmailTaken {
+							log.Printf("profile POST: claim email error for '%s': %v", username, err)
+						}
+						http.Redirect(w, r, "/profile?notice=email_taken", http.StatusSeeOther)
+						return
+					}
+					if err := repo.UpdateEmail(ctx, u.ID, email); err != nil {
+						log.Printf("profile POST: update email error for '%s': %v", username, err)
+						http.Redirect(w, r, "/profile?notice=email_taken", http.StatusSeeOther)
+						return
+					}
+					u.Email = email
+					if err := sendVerification(ctx, u); err != nil {
+						log.Printf("profile POST: send verification to '%s': %v", username, err)
+					}
+					http.Redirect(w, r, "/profile?notice=verification_sent", http.StatusSeeOther)
+					return
+				}
 			}
 		}
 
@@ -80,7 +112,9 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	data := ProfileData{
 		Username: username,
 		Avatar:   "/static/avatars/avatar1.png",
-		ELO:      1200,
+		ELO:      DefaultRating,
+		CSRF:     CSRFToken(w, r),
+		Notice:   profileNotices[r.URL.Query().Get("notice")],
 	}
 
 	if repo != nil {
@@ -88,6 +122,13 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 		if u, err := repo.GetByUsername(ctx, username); err == nil && u != nil {
 			data.Username = u.Username
 			data.Email = u.Email
+			data.EmailVerified = u.EmailVerified
+			if tf, err := twoFactorStore.GetTwoFactor(ctx, u.ID); err != nil {
+				log.Printf("profile: two-factor lookup for '%s': %v", username, err)
+			} else if tf != nil && tf.Enabled {
+				data.TwoFactor = true
+				data.RecoveryLeft = tf.RecoveryLeft
+			}
 			if u.AvatarURL != "" {
 				data.Avatar = u.AvatarURL
 			} else {
@@ -95,43 +136,25 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// Si on a un mysqlRepo, on essaie de récupérer le rating + quelques stats
-		if mr, ok := repo.(*mysqlRepo); ok {
-			userID := dataFromUserID(repo, username)
-
-			// ELO (table user_ratings)
-			if userID != 0 {
-				var rating sql.NullInt64
-				err := mr.db.QueryRowContext(ctx,
-					"SELECT rating FROM user_ratings WHERE user_id = ?",
-					userID,
-				).Scan(&rating)
-				if err != nil && err != sql.ErrNoRows {
-					log.Printf("profile: rating query error for user %s: %v", username, err)
-				}
-				if rating.Valid {
-					data.ELO = int(rating.Int64)
-				}
-			}
+		// ELO (table user_ratings ou store mémoire)
+		if userID := dataFromUserID(repo, username); userID != 0 {
+			rt := ratingInfo(ctx, userID)
+			data.ELO = rt.Rating
+			data.RatingLow, data.RatingHigh = ratingSystem.Interval(rt)
+			data.Provisional = ratingSystem.Provisional(rt)
+		}
 
-			// (Optionnel) Stats de parties si tu as une table games
-			var gp, wins sql.NullInt64
-			err := mr.db.QueryRowContext(ctx, `
-				SELECT 
-					COUNT(*) AS gp,
-					SUM(CASE WHEN status='finished' AND winner_id = ? THEN 1 ELSE 0 END) AS wins
-				FROM games
-				WHERE player1_id = ? OR player2_id = ?`,
-				userID, userID, userID,
-			).Scan(&gp, &wins)
-			if err != nil && err != sql.ErrNoRows {
-				log.Printf("profile: stats query error for user %s: %v", username, err)
-			}
-			if gp.Valid {
-				data.GamesPlayed = int(gp.Int64)
-			}
-			if wins.Valid {
-				data.Wins = int(wins.Int64)
+		// Stats de parties (table games ou store mémoire)
+		if gameStore != nil {
+			if userID := dataFromUserID(repo, username); userID != 0 {
+				st, err := gameStore.Stats(ctx, userID)
+				if err != nil {
+					log.Printf("profile: stats query error for user %s: %v", username, err)
+				}
+				data.GamesPlayed = st.Played
+				data.Wins = st.Wins
+				data.Losses = st.Losses
+				data.Draws = st.Draws
 			}
 		}
 	}
@@ -155,6 +178,8 @@ type PlayerRow struct {
 	DisplayName string
 	Avatar      string
 	Rating      int
+	RatingLow   int
+	RatingHigh  int
 	Rank        string
 	GamesPlayed int
 	Wins        int
@@ -166,53 +191,26 @@ type LeaderboardData struct {
 	Players []PlayerRow
 }
 
-// LeaderboardHandler : classement trié par ELO
+// LeaderboardHandler : classement trié par ELO (joueurs au classement
+// provisoire ou sans email vérifié exclus)
 func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
 	data := LeaderboardData{}
 	ctx := context.Background()
+	fromDB := false
 
 	// Si on a du MySQL, on essaie de récupérer les vrais ELO
 	if mr, ok := repo.(*mysqlRepo); ok {
-		rows, err := mr.db.QueryContext(ctx, `
-			SELECT u.id, u.username, u.avatar_url,
-			       COALESCE(r.rating, 1200) AS rating
-			FROM users u
-			LEFT JOIN user_ratings r ON r.user_id = u.id
-			ORDER BY rating DESC, u.username ASC
-			LIMIT 50`)
+		players, err := mr.leaderboard(ctx, leaderboardSize)
 		if err != nil {
 			log.Printf("leaderboard: query error: %v", err)
 		} else {
-			defer rows.Close()
-			for rows.Next() {
-				var (
-					id        int
-					username  string
-					avatarURL sql.NullString
-					rating    int
-				)
-				if err := rows.Scan(&id, &username, &avatarURL, &rating); err != nil {
-					log.Printf("leaderboard: scan error: %v", err)
-					continue
-				}
-				avatar := pickAvatar(id)
-				if avatarURL.Valid && avatarURL.String != "" {
-					avatar = avatarURL.String
-				}
-				row := PlayerRow{
-					Username:    username,
-					DisplayName: username,
-					Avatar:      avatar,
-					Rating:      rating,
-					Rank:        RankFromELO(rating),
-				}
-				data.Players = append(data.Players, row)
-			}
+			fromDB = true
+			data.Players = players
 		}
 	}
 
 	// Pas de DB ou erreur → faux leaderboard
-	if len(data.Players) == 0 {
+	if !fromDB {
 		for i := 1; i <= 8; i++ {
 			name := "Joueur" + strconv.Itoa(i)
 			rating := 1200 - i*5
@@ -238,6 +236,77 @@ func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// leaderboardSize : nombre de joueurs affichés ; leaderboardPage : lignes
+// lues par requête. Le statut provisoire dépend du système de classement
+// (parties jouées, RD qui croît avec l'inactivité) et se calcule en Go : on
+// lit donc page par page jusqu'à trouver assez de joueurs établis.
+const (
+	leaderboardSize = 50
+	leaderboardPage = 200
+)
+
+// leaderboard : les n meilleurs joueurs vérifiés au classement établi.
+func (m *mysqlRepo) leaderboard(ctx context.Context, n int) ([]PlayerRow, error) {
+	var out []PlayerRow
+	for offset := 0; len(out) < n; offset += leaderboardPage {
+		rows, err := m.db.QueryContext(ctx, `
+			SELECT u.id, u.username, u.avatar_url,
+			       COALESCE(r.rating, 1200) AS rating,
+			       COALESCE(r.games_count, 0), COALESCE(r.rd, 350), COALESCE(r.volatility, 0.06),
+			       r.updated_at
+			FROM users u
+			LEFT JOIN user_ratings r ON r.user_id = u.id
+			WHERE u.email_verified_at IS NOT NULL
+			ORDER BY rating DESC, u.username ASC, u.id ASC
+			LIMIT ? OFFSET ?`, leaderboardPage, offset)
+		if err != nil {
+			return nil, err
+		}
+		read := 0
+		for rows.Next() {
+			read++
+			var (
+				id        int
+				username  string
+				avatarURL sql.NullString
+				rt        Rating
+				updated   sql.NullTime
+			)
+			if err := rows.Scan(&id, &username, &avatarURL, &rt.Rating, &rt.Games, &rt.RD, &rt.Volatility, &updated); err != nil {
+				log.Printf("leaderboard: scan error: %v", err)
+				continue
+			}
+			rt.UpdatedAt = updated.Time
+			if ratingSystem.Provisional(rt) || len(out) >= n {
+				continue
+			}
+			lo, hi := ratingSystem.Interval(rt)
+			avatar := pickAvatar(id)
+			if avatarURL.Valid && avatarURL.String != "" {
+				avatar = avatarURL.String
+			}
+			out = append(out, PlayerRow{
+				Username:    username,
+				DisplayName: username,
+				Avatar:      avatar,
+				Rating:      rt.Rating,
+				RatingLow:   lo,
+				RatingHigh:  hi,
+				Rank:        RankFromELO(rt.Rating),
+			})
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		if read < leaderboardPage {
+			break // plus de joueurs
+		}
+	}
+	return out, nil
+}
+
 // --------- HELPERS ---------
 
 // dataFromUserID récupère l'ID num d'un user à partir de son username.
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultRating : classement d'un joueur qui n'a encore jamais été classé.
+const DefaultRating = 1200
+
+// Rating : classement d'un joueur (table user_ratings). RD et Volatility ne
+// servent qu'au système Glicko-2.
+type Rating struct {
+	Rating     int
+	Games      int // nombre de parties classées jouées
+	RD         float64
+	Volatility float64
+	UpdatedAt  time.Time // dernière partie classée (zéro si jamais classé)
+}
+
+// unrated : classement d'un joueur sans ligne user_ratings.
+func unrated() Rating {
+	return Rating{Rating: DefaultRating, RD: DefaultRD, Volatility: DefaultVolatility}
+}
+
+// RatingStore is the persistence abstraction for user_ratings.
+type RatingStore interface {
+	// GetRating returns the rating of a user (DefaultRating if unrated).
+	GetRating(ctx context.Context, userID int) (Rating, error)
+	// UpdateRatings reads the ratings of a and b, passes them to update and
+	// writes the results back atomically.
+	UpdateRatings(ctx context.Context, a, b int, update func(ra, rb Rating) (Rating, Rating)) error
+}
+
+// RatingSystem calcule les nouveaux classements après une partie ;
+// scoreA vaut 1 (victoire de a), 0.5 (nul) ou 0 (défaite).
+type RatingSystem interface {
+	Rate(a, b Rating, scoreA float64) (Rating, Rating)
+	// Provisional indique un classement encore trop incertain pour le leaderboard.
+	Provisional(r Rating) bool
+	// Interval renvoie l'intervalle de confiance du classement.
+	Interval(r Rating) (lo, hi int)
+}
+
+// RatingSystemFromEnv choisit le système via RATING_SYSTEM ("elo" par défaut, ou "glicko2").
+func RatingSystemFromEnv() RatingSystem {
+	switch v := os.Getenv("RATING_SYSTEM"); v {
+	case "glicko2", "glicko":
+		log.Println("auth: using Glicko-2 ratings")
+		return Glicko2FromEnv()
+	case "", "elo":
+		log.Println("auth: using Elo ratings")
+	default:
+		log.Printf("auth: unknown RATING_SYSTEM %q — using Elo", v)
+	}
+	return EloFromEnv()
+}
+
+// Elo : mise à jour Elo classique. Les joueurs ayant moins de
+// ProvisionalGames parties utilisent KProvisional (convergence plus rapide).
+type Elo struct {
+	K                int
+	KProvisional     int
+	ProvisionalGames int
+}
+
+// DefaultElo : K=32, K=64 pendant les 10 premières parties.
+var DefaultElo = Elo{K: 32, KProvisional: 64, ProvisionalGames: 10}
+
+// EloFromEnv lit ELO_K, ELO_K_PROVISIONAL et ELO_PROVISIONAL_GAMES.
+func EloFromEnv() Elo {
+	e := DefaultElo
+	for _, v := range []struct {
+		key string
+		dst *int
+	}{{"ELO_K", &e.K}, {"ELO_K_PROVISIONAL", &e.KProvisional}, {"ELO_PROVISIONAL_GAMES", &e.ProvisionalGames}} {
+		if n, err := strconv.Atoi(os.Getenv(v.key)); err == nil && n >= 0 {
+			*v.dst = n
+		}
+	}
+	return e
+}
+
+// Expected : probabilité de victoire de a contre b selon Elo.
+func Expected(a, b int) float64 {
+	return 1 / (1 + math.Pow(10, float64(b-a)/400))
+}
+
+func (e Elo) k(r Rating) float64 {
+	if r.Games < e.ProvisionalGames {
+		return float64(e.KProvisional)
+	}
+	return float64(e.K)
+}
+
+// Rate applique la formule Elo aux deux joueurs.
+func (e Elo) Rate(a, b Rating, scoreA float64) (Rating, Rating) {
+	ea := Expected(a.Rating, b.Rating)
+	na, nb := a, b
+	na.Rating = a.Rating + int(math.Round(e.k(a)*(scoreA-ea)))
+	nb.Rating = b.Rating + int(math.Round(e.k(b)*((1-scoreA)-(1-ea))))
+	na.Games++
+	nb.Games++
+	na.UpdatedAt, nb.UpdatedAt = time.Now(), time.Now()
+	return na, nb
+}
+
+// Provisional : moins de ProvisionalGames parties classées.
+func (e Elo) Provisional(r Rating) bool { return r.Games < e.ProvisionalGames }
+
+// Interval : Elo n'a pas de mesure d'incertitude, l'intervalle est réduit au classement.
+func (e Elo) Interval(r Rating) (lo, hi int) { return r.Rating, r.Rating }
+
+var (
+	ratingStore  RatingStore
+	ratingSystem RatingSystem = DefaultElo
+)
+
+// Ratings renvoie le RatingStore configuré par Init.
+func Ratings() RatingStore { return ratingStore }
+
+// RatingOf renvoie le classement de userID (DefaultRating en cas d'erreur).
+func RatingOf(ctx context.Context, userID int) int {
+	return ratingInfo(ctx, userID).Rating
+}
+
+// ratingInfo renvoie le classement complet de userID (non classé en cas d'erreur).
+func ratingInfo(ctx context.Context, userID int) Rating {
+	if ratingStore == nil || userID == 0 {
+		return unrated()
+	}
+	r, err := ratingStore.GetRating(ctx, userID)
+	if err != nil {
+		log.Printf("rating: lookup error for user %d: %v", userID, err)
+		return unrated()
+	}
+	return r
+}
+
+// RecordResult met à jour le classement des deux joueurs d'une partie classée
+// terminée ; scoreP1 vaut 1, 0.5 ou 0 du point de vue de p1.
+func RecordResult(ctx context.Context, p1, p2 int, scoreP1 float64) error {
+	if ratingStore == nil || p1 == 0 || p2 == 0 || p1 == p2 {
+		return nil
+	}
+	return ratingStore.UpdateRatings(ctx, p1, p2, func(a, b Rating) (Rating, Rating) {
+		return ratingSystem.Rate(a, b, scoreP1)
+	})
+}
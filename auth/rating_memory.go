@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+type memoryRatingStore struct {
+	mu      sync.Mutex
+	ratings map[int]Rating
+}
+
+func NewMemoryRatingStore() RatingStore {
+	return &memoryRatingStore{ratings: make(map[int]Rating)}
+}
+
+func (m *memoryRatingStore) get(userID int) Rating {
+	if r, ok := m.ratings[userID]; ok {
+		return r
+	}
+	return unrated()
+}
+
+func (m *memoryRatingStore) GetRating(ctx context.Context, userID int) (Rating, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.get(userID), nil
+}
+
+func (m *memoryRatingStore) UpdateRatings(ctx context.Context, a, b int, update func(ra, rb Rating) (Rating, Rating)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ra, rb := update(m.get(a), m.get(b))
+	m.ratings[a] = ra
+	m.ratings[b] = rb
+	return nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+)
+
+type mysqlRatingStore struct {
+	db *sql.DB
+}
+
+// NewMySQLRatingStore wraps the connection of a MySQL repository (table user_ratings).
+func NewMySQLRatingStore(db *sql.DB) RatingStore {
+	return &mysqlRatingStore{db: db}
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// getRating lit une ligne user_ratings ; suffix permet d'ajouter FOR UPDATE.
+func getRating(ctx context.Context, q queryRower, userID int, suffix string) (Rating, error) {
+	var r Rating
+	err := q.QueryRowContext(ctx,
+		"SELECT rating, games_count, rd, volatility, updated_at FROM user_ratings WHERE user_id = ?"+suffix,
+		userID,
+	).Scan(&r.Rating, &r.Games, &r.RD, &r.Volatility, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return unrated(), nil
+	}
+	return r, err
+}
+
+func (m *mysqlRatingStore) GetRating(ctx context.Context, userID int) (Rating, error) {
+	return getRating(ctx, m.db, userID, "")
+}
+
+// UpdateRatings verrouille les deux lignes (FOR UPDATE), calcule et écrit
+// les nouveaux classements dans une seule transaction.
+func (m *mysqlRatingStore) UpdateRatings(ctx context.Context, a, b int, update func(ra, rb Rating) (Rating, Rating)) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// ordre fixe des verrous pour éviter les interblocages
+	first, second := a, b
+	if second < first {
+		first, second = second, first
+	}
+	r1, err := getRating(ctx, tx, first, " FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	r2, err := getRating(ctx, tx, second, " FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	ra, rb := r1, r2
+	if first != a {
+		ra, rb = r2, r1
+	}
+
+	ra, rb = update(ra, rb)
+	for _, row := range []struct {
+		id int
+		r  Rating
+	}{{a, ra}, {b, rb}} {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_ratings (user_id, rating, games_count, rd, volatility) VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE rating = VALUES(rating), games_count = VALUES(games_count),
+			                        rd = VALUES(rd), volatility = VALUES(volatility)`,
+			row.id, row.r.Rating, row.r.Games, row.r.RD, row.r.Volatility,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
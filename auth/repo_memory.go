@@ -68,6 +68,22 @@ func (m *memoryRepo) GetByID(ctx context.Context, id int) (*User, error) {
 	return nil, nil
 }
 
+func (m *memoryRepo) GetByEmail(ctx context.Context, email string) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, nil
+	}
+	for _, mu := range m.byName {
+		if strings.EqualFold(mu.u.Email, email) {
+			u := mu.u
+			return &u, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *memoryRepo) Authenticate(ctx context.Context, username, password string) (*User, error) {
 	m.mu.RLock()
 	mu, ok := m.byName[username]
@@ -109,3 +125,50 @@ func (m *memoryRepo) UpdateAvatar(ctx context.Context, id int, avatarURL string)
 	}
 	return errors.New("not found")
 }
+
+// UpdatePassword rehashes the password of a user by ID.
+func (m *memoryRepo) UpdatePassword(ctx context.Context, id int, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mu := range m.byName {
+		if mu.u.ID == id {
+			mu.hash = hash
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+// UpdateEmail sets the email of a user by ID, unverified.
+func (m *memoryRepo) UpdateEmail(ctx context.Context, id int, email string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mu := range m.byName {
+		if mu.u.ID == id {
+			mu.u.Email = email
+			mu.u.EmailVerified = false
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+// MarkEmailVerified flags the email of a user by ID as verified.
+func (m *memoryRepo) MarkEmailVerified(ctx context.Context, id int, email string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mu := range m.byName {
+		if mu.u.ID == id {
+			if mu.u.Email != email {
+				return false, nil
+			}
+			mu.u.EmailVerified = true
+			return true, nil
+		}
+	}
+	return false, errors.New("not found")
+}
@@ -79,7 +79,7 @@ func (m *mysqlRepo) CreateUser(ctx context.Context, username, email, password st
 	}
 	res, err := m.db.ExecContext(ctx,
 		"INSERT INTO users (username, email, password_hash, created_at) VALUES (?, ?, ?, NOW())",
-		username, email, string(hash),
+		username, nullIfEmpty(email), string(hash),
 	)
 	if err != nil {
 		return nil, err
@@ -91,19 +91,20 @@ func (m *mysqlRepo) CreateUser(ctx context.Context, username, email, password st
 // GetByUsername returns a user by username (supporte avatar_url NULL).
 func (m *mysqlRepo) GetByUsername(ctx context.Context, username string) (*User, error) {
 	row := m.db.QueryRowContext(ctx,
-		"SELECT id, username, email, avatar_url FROM users WHERE username=?",
+		"SELECT id, username, email, avatar_url, email_verified_at IS NOT NULL FROM users WHERE username=?",
 		username,
 	)
 
 	var u User
-	var avatar sql.NullString
+	var email, avatar sql.NullString
 
-	if err := row.Scan(&u.ID, &u.Username, &u.Email, &avatar); err != nil {
+	if err := row.Scan(&u.ID, &u.Username, &email, &avatar, &u.EmailVerified); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	u.Email = email.String
 	if avatar.Valid {
 		u.AvatarURL = avatar.String
 	} else {
@@ -115,19 +116,20 @@ func (m *mysqlRepo) GetByUsername(ctx context.Context, username string) (*User,
 // GetByID returns a user by id (supporte avatar_url NULL).
 func (m *mysqlRepo) GetByID(ctx context.Context, id int) (*User, error) {
 	row := m.db.QueryRowContext(ctx,
-		"SELECT id, username, email, avatar_url FROM users WHERE id=?",
+		"SELECT id, username, email, avatar_url, email_verified_at IS NOT NULL FROM users WHERE id=?",
 		id,
 	)
 
 	var u User
-	var avatar sql.NullString
+	var email, avatar sql.NullString
 
-	if err := row.Scan(&u.ID, &u.Username, &u.Email, &avatar); err != nil {
+	if err := row.Scan(&u.ID, &u.Username, &email, &avatar, &u.EmailVerified); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
+	u.Email = email.String
 	if avatar.Valid {
 		u.AvatarURL = avatar.String
 	} else {
@@ -136,12 +138,39 @@ func (m *mysqlRepo) GetByID(ctx context.Context, id int) (*User, error) {
 	return &u, nil
 }
 
+// GetByEmail returns a user by email (supporte avatar_url NULL).
+func (m *mysqlRepo) GetByEmail(ctx context.Context, email string) (*User, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, nil
+	}
+	row := m.db.QueryRowContext(ctx,
+		"SELECT id, username, email, avatar_url, email_verified_at IS NOT NULL FROM users WHERE email=?",
+		email,
+	)
+
+	var u User
+	var mail, avatar sql.NullString
+
+	if err := row.Scan(&u.ID, &u.Username, &mail, &avatar, &u.EmailVerified); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	u.Email = mail.String
+	if avatar.Valid {
+		u.AvatarURL = avatar.String
+	}
+	return &u, nil
+}
+
 // Authenticate checks username/password (supporte avatar_url NULL + $2y$ provenant de PHP).
 func (m *mysqlRepo) Authenticate(ctx context.Context, username, password string) (*User, error) {
 	username = strings.TrimSpace(username)
 
 	row := m.db.QueryRowContext(ctx,
-		"SELECT id, password_hash, username, email, avatar_url FROM users WHERE username=?",
+		"SELECT id, password_hash, username, email, avatar_url, email_verified_at IS NOT NULL FROM users WHERE username=?",
 		username,
 	)
 
@@ -149,16 +178,18 @@ func (m *mysqlRepo) Authenticate(ctx context.Context, username, password string)
 		id     int
 		hash   string
 		u      User
+		email  sql.NullString
 		avatar sql.NullString
 	)
 
-	if err := row.Scan(&id, &hash, &u.Username, &u.Email, &avatar); err != nil {
+	if err := row.Scan(&id, &hash, &u.Username, &email, &avatar, &u.EmailVerified); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("not found")
 		}
 		return nil, err
 	}
 
+	u.Email = email.String
 	if avatar.Valid {
 		u.AvatarURL = avatar.String
 	} else {
@@ -190,3 +221,49 @@ func (m *mysqlRepo) UpdateAvatar(ctx context.Context, id int, avatarURL string)
 	_, err := m.db.ExecContext(ctx, "UPDATE users SET avatar_url = ? WHERE id = ?", avatarURL, id)
 	return err
 }
+
+// UpdatePassword stores a new bcrypt hash for a user.
+func (m *mysqlRepo) UpdatePassword(ctx context.Context, id int, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, "UPDATE users SET password_hash = ? WHERE id = ?", string(hash), id)
+	return err
+}
+
+// UpdateEmail changes the email of a user (NULL when empty, so that
+// uq_users_email ignores it) and clears its verification.
+func (m *mysqlRepo) UpdateEmail(ctx context.Context, id int, email string) error {
+	_, err := m.db.ExecContext(ctx,
+		"UPDATE users SET email = ?, email_verified_at = NULL WHERE id = ?",
+		nullIfEmpty(email), id,
+	)
+	return err
+}
+
+// MarkEmailVerified sets email_verified_at if the email is still the one
+// the verification link was sent to.
+func (m *mysqlRepo) MarkEmailVerified(ctx context.Context, id int, email string) (bool, error) {
+	if _, err := m.db.ExecContext(ctx,
+		"UPDATE users SET email_verified_at = NOW() WHERE id = ? AND email = ? AND email_verified_at IS NULL",
+		id, email,
+	); err != nil {
+		return false, err
+	}
+	// RowsAffected ne compte que les lignes modifiées : on relit
+	var n int
+	err := m.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM users WHERE id = ? AND email = ? AND email_verified_at IS NOT NULL",
+		id, email,
+	).Scan(&n)
+	return n == 1, err
+}
+
+// nullIfEmpty : NULL SQL pour une chaîne vide.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -8,6 +8,9 @@ type User struct {
 	Username  string
 	Email     string
 	AvatarURL string
+	// EmailVerified is true once the current Email has been confirmed
+	// through a verification link (see verify.go).
+	EmailVerified bool
 }
 
 // Repository is the persistence abstraction for users.
@@ -15,10 +18,19 @@ type Repository interface {
 	CreateUser(ctx context.Context, username, email, password string) (*User, error)
 	GetByUsername(ctx context.Context, username string) (*User, error)
 	GetByID(ctx context.Context, id int) (*User, error)
+	// GetByEmail returns the user with this email (nil if none).
+	GetByEmail(ctx context.Context, email string) (*User, error)
 	Authenticate(ctx context.Context, username, password string) (*User, error)
 	// DeleteUser removes a user by ID.
 	DeleteUser(ctx context.Context, id int) error
 	// UpdateAvatar sets the avatar URL for a user by ID.
 	UpdateAvatar(ctx context.Context, id int, avatarURL string) error
+	// UpdatePassword stores a new bcrypt hash of password for a user by ID.
+	UpdatePassword(ctx context.Context, id int, password string) error
+	// UpdateEmail changes the email of a user ("" clears it) and marks it unverified.
+	UpdateEmail(ctx context.Context, id int, email string) error
+	// MarkEmailVerified marks the email of a user verified if it is still
+	// email; ok is false when the address changed in the meantime.
+	MarkEmailVerified(ctx context.Context, id int, email string) (ok bool, err error)
 	Close() error
 }
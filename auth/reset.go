@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// --------- MOT DE PASSE OUBLIÉ ---------
+
+// resetTTL : durée de validité d'un lien de réinitialisation
+const resetTTL = time.Hour
+
+// PasswordReset : une ligne de la table password_resets. Comme pour les
+// sessions, seul le hash SHA-256 du jeton envoyé par email est stocké.
+type PasswordReset struct {
+	TokenHash string
+	UserID    int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ResetStore is the persistence abstraction for password reset tokens.
+type ResetStore interface {
+	CreateReset(ctx context.Context, pr PasswordReset) error
+	// GetReset returns the unused, unexpired reset with this hash (nil if none).
+	GetReset(ctx context.Context, tokenHash string) (*PasswordReset, error)
+	// ConsumeReset marks the reset used and returns it, atomically: a token
+	// can be consumed once (nil if unknown, used or expired).
+	ConsumeReset(ctx context.Context, tokenHash string, now time.Time) (*PasswordReset, error)
+	// DeleteUserResets removes the pending resets of a user.
+	DeleteUserResets(ctx context.Context, userID int) error
+	// RecentResets counts the links sent to a user since since and returns
+	// the time of the latest one (zero if none).
+	RecentResets(ctx context.Context, userID int, since time.Time) (count int, last time.Time, err error)
+}
+
+// resetStore : implémentation choisie dans Init (MySQL si dispo, sinon mémoire)
+var resetStore ResetStore
+
+// ForgotPasswordHandler : GET = formulaire / POST = envoi du lien par email,
+// seulement vers une adresse vérifiée, avec les mêmes limites que les liens
+// de vérification (resendInterval, maxDailySends). La réponse est la même
+// que l'adresse soit connue ou non, limitée ou non.
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		renderForm(w, r, "forgot_password.gohtml", "")
+		return
+	}
+
+	email := strings.TrimSpace(r.FormValue("email"))
+	if email == "" {
+		renderForm(w, r, "forgot_password.gohtml", "Adresse email requise.")
+		return
+	}
+
+	ctx := r.Context()
+	u, err := repo.GetByEmail(ctx, email)
+	if err != nil {
+		log.Printf("forgot_password: GetByEmail error: %v", err)
+	}
+	if u != nil && u.EmailVerified { // jamais vers une adresse non confirmée
+		now := time.Now()
+		n, last, err := resetStore.RecentResets(ctx, u.ID, now.Add(-24*time.Hour))
+		switch {
+		case err != nil:
+			log.Printf("forgot_password: RecentResets error for user %d: %v", u.ID, err)
+		case n >= maxDailySends || now.Sub(last) < resendInterval:
+			log.Printf("forgot_password: rate limited for user %d", u.ID)
+		default:
+			if err := sendResetLink(ctx, u); err != nil {
+				log.Printf("forgot_password: send link to user %d: %v", u.ID, err)
+			}
+		}
+	}
+
+	_ = tpl.ExecuteTemplate(w, "forgot_password.gohtml", formPage{
+		Info: "Si un compte utilise cette adresse, un lien de réinitialisation vient d'y être envoyé (valable 1 heure).",
+		CSRF: CSRFToken(w, r),
+	})
+}
+
+// sendResetLink crée un jeton de réinitialisation pour u et l'envoie par email.
+func sendResetLink(ctx context.Context, u *User) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := resetStore.CreateReset(ctx, PasswordReset{
+		TokenHash: hashToken(token),
+		UserID:    u.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(resetTTL),
+	}); err != nil {
+		return err
+	}
+
+	link := goBase() + "/reset_password?token=" + url.QueryEscape(token)
+	return mailer.Send(ctx, Message{
+		To:      u.Email,
+		Subject: "Power4 — réinitialisation du mot de passe",
+		Body: "Bonjour " + u.Username + ",\n\n" +
+			"Pour choisir un nouveau mot de passe, ouvre ce lien (valable 1 heure) :\n" +
+			link + "\n\n" +
+			"Si tu n'as rien demandé, ignore simplement ce message.\n",
+	})
+}
+
+// ResetPasswordHandler : GET = formulaire (jeton dans ?token=) / POST =
+// nouveau mot de passe. Le jeton est consommé, toutes les sessions du
+// compte sont fermées.
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.FormValue("token")
+	page := formPage{Token: token, CSRF: CSRFToken(w, r)}
+	const invalid = "Lien invalide ou expiré. Refais une demande de réinitialisation."
+
+	if r.Method != http.MethodPost {
+		if pr, err := resetStore.GetReset(ctx, hashToken(token)); err != nil || pr == nil {
+			page.Error = invalid
+			page.Token = ""
+		}
+		_ = tpl.ExecuteTemplate(w, "reset_password.gohtml", page)
+		return
+	}
+
+	password := r.FormValue("password")
+	if password == "" || password != r.FormValue("confirm") {
+		page.Error = "Les deux mots de passe doivent être identiques et non vides."
+		_ = tpl.ExecuteTemplate(w, "reset_password.gohtml", page)
+		return
+	}
+
+	pr, err := resetStore.ConsumeReset(ctx, hashToken(token), time.Now())
+	if err != nil {
+		log.Printf("reset_password: ConsumeReset error: %v", err)
+	}
+	if pr == nil {
+		page.Error = invalid
+		page.Token = ""
+		_ = tpl.ExecuteTemplate(w, "reset_password.gohtml", page)
+		return
+	}
+
+	if err := repo.UpdatePassword(ctx, pr.UserID, password); err != nil {
+		log.Printf("reset_password: UpdatePassword error for user %d: %v", pr.UserID, err)
+		http.Error(w, "password update error", http.StatusInternalServerError)
+		return
+	}
+	if err := sessionStore.DeleteUserSessions(ctx, pr.UserID); err != nil {
+		log.Printf("reset_password: DeleteUserSessions error for user %d: %v", pr.UserID, err)
+	}
+	if err := resetStore.DeleteUserResets(ctx, pr.UserID); err != nil {
+		log.Printf("reset_password: DeleteUserResets error for user %d: %v", pr.UserID, err)
+	}
+	log.Printf("reset_password: password changed for user %d", pr.UserID)
+
+	renderInfo(w, r, "login.gohtml", "Mot de passe modifié. Tu peux te connecter.")
+}
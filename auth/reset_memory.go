@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryResetStore struct {
+	mu     sync.Mutex
+	resets map[string]PasswordReset
+}
+
+func NewMemoryResetStore() ResetStore {
+	return &memoryResetStore{resets: make(map[string]PasswordReset)}
+}
+
+func (m *memoryResetStore) CreateReset(ctx context.Context, pr PasswordReset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resets[pr.TokenHash] = pr
+	return nil
+}
+
+func (m *memoryResetStore) GetReset(ctx context.Context, tokenHash string) (*PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pr, ok := m.resets[tokenHash]
+	if !ok || !time.Now().Before(pr.ExpiresAt) {
+		return nil, nil
+	}
+	return &pr, nil
+}
+
+// ConsumeReset : un jeton utilisé est retiré de la map.
+func (m *memoryResetStore) ConsumeReset(ctx context.Context, tokenHash string, now time.Time) (*PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pr, ok := m.resets[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(m.resets, tokenHash)
+	if !now.Before(pr.ExpiresAt) {
+		return nil, nil
+	}
+	return &pr, nil
+}
+
+func (m *memoryResetStore) DeleteUserResets(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, pr := range m.resets {
+		if pr.UserID == userID {
+			delete(m.resets, k)
+		}
+	}
+	return nil
+}
+
+func (m *memoryResetStore) RecentResets(ctx context.Context, userID int, since time.Time) (int, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var (
+		n    int
+		last time.Time
+	)
+	for _, pr := range m.resets {
+		if pr.UserID != userID || pr.CreatedAt.Before(since) {
+			continue
+		}
+		n++
+		if pr.CreatedAt.After(last) {
+			last = pr.CreatedAt
+		}
+	}
+	return n, last, nil
+}
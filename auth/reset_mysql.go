@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type mysqlResetStore struct {
+	db *sql.DB
+}
+
+// NewMySQLResetStore wraps the connection of a MySQL repository (table password_resets).
+func NewMySQLResetStore(db *sql.DB) ResetStore {
+	return &mysqlResetStore{db: db}
+}
+
+func (m *mysqlResetStore) CreateReset(ctx context.Context, pr PasswordReset) error {
+	_, err := m.db.ExecContext(ctx,
+		"INSERT INTO password_resets (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		pr.TokenHash, pr.UserID, pr.CreatedAt, pr.ExpiresAt,
+	)
+	return err
+}
+
+func (m *mysqlResetStore) GetReset(ctx context.Context, tokenHash string) (*PasswordReset, error) {
+	pr := PasswordReset{TokenHash: tokenHash}
+	err := m.db.QueryRowContext(ctx, `
+		SELECT user_id, created_at, expires_at FROM password_resets
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`,
+		tokenHash, time.Now(),
+	).Scan(&pr.UserID, &pr.CreatedAt, &pr.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// ConsumeReset : l'UPDATE conditionnel (used_at IS NULL) garantit qu'un
+// seul appel concurrent réussit.
+func (m *mysqlResetStore) ConsumeReset(ctx context.Context, tokenHash string, now time.Time) (*PasswordReset, error) {
+	res, err := m.db.ExecContext(ctx, `
+		UPDATE password_resets SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`,
+		now, tokenHash, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return nil, err
+	}
+	pr := PasswordReset{TokenHash: tokenHash}
+	err = m.db.QueryRowContext(ctx,
+		"SELECT user_id, created_at, expires_at FROM password_resets WHERE token_hash = ?",
+		tokenHash,
+	).Scan(&pr.UserID, &pr.CreatedAt, &pr.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (m *mysqlResetStore) DeleteUserResets(ctx context.Context, userID int) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = ?", userID)
+	return err
+}
+
+func (m *mysqlResetStore) RecentResets(ctx context.Context, userID int, since time.Time) (int, time.Time, error) {
+	var (
+		n    int
+		last sql.NullTime
+	)
+	err := m.db.QueryRowContext(ctx,
+		"SELECT COUNT(*), MAX(created_at) FROM password_resets WHERE user_id = ? AND created_at >= ?",
+		userID, since,
+	).Scan(&n, &last)
+	return n, last.Time, err
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResetTokenSingleUse(t *testing.T) {
+	m := setupAuth(t)
+	newUser(t, "alice", "alice@example.com")
+	session := login(t, "alice", "secret")
+
+	serve(ForgotPasswordHandler, "/forgot_password", url.Values{"email": {"alice@example.com"}}, nil)
+	token := m.lastToken(t)
+
+	reset := func(password string) string {
+		return serve(ResetPasswordHandler, "/reset_password", url.Values{
+			"token": {token}, "password": {password}, "confirm": {password},
+		}, nil).Body.String()
+	}
+	if body := reset("new-secret"); !strings.Contains(body, "Mot de passe modifié") {
+		t.Fatalf("first reset: %q", body)
+	}
+	if u, _ := repo.Authenticate(context.Background(), "alice", "new-secret"); u == nil {
+		t.Fatal("new password refused")
+	}
+	if got := userOf(session); got != "" {
+		t.Errorf("session opened before the reset still valid for %q", got)
+	}
+
+	if body := reset("third-secret"); !strings.Contains(body, "Lien invalide") {
+		t.Errorf("second reset with the same token: %q", body)
+	}
+	if u, _ := repo.Authenticate(context.Background(), "alice", "third-secret"); u != nil {
+		t.Error("reused token changed the password")
+	}
+}
+
+func TestResetTokenExpired(t *testing.T) {
+	setupAuth(t)
+	u := newUser(t, "alice", "alice@example.com")
+	past := time.Now().Add(-2 * resetTTL)
+	if err := resetStore.CreateReset(context.Background(), PasswordReset{
+		TokenHash: hashToken("old"),
+		UserID:    u.ID,
+		CreatedAt: past,
+		ExpiresAt: past.Add(resetTTL),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	body := serve(ResetPasswordHandler, "/reset_password", url.Values{
+		"token": {"old"}, "password": {"x"}, "confirm": {"x"},
+	}, nil).Body.String()
+	if !strings.Contains(body, "Lien invalide") {
+		t.Errorf("expired token: %q", body)
+	}
+}
+
+// forgot demande un lien de réinitialisation pour email.
+func forgot(email string) *http.Response {
+	return serve(ForgotPasswordHandler, "/forgot_password", url.Values{"email": {email}}, nil).Result()
+}
+
+func TestForgotPasswordRateLimit(t *testing.T) {
+	m := setupAuth(t)
+	newUser(t, "alice", "alice@example.com")
+	forgot("alice@example.com")
+	forgot("alice@example.com") // avant resendInterval
+	if n := m.count(); n != 1 {
+		t.Errorf("%d emails for two requests in a row, want 1", n)
+	}
+
+	// maxDailySends liens déjà envoyés dans les dernières 24 h
+	m = setupAuth(t)
+	u := newUser(t, "alice", "alice@example.com")
+	for i := 0; i < maxDailySends; i++ {
+		at := time.Now().Add(-time.Duration(i+1) * time.Hour)
+		if err := resetStore.CreateReset(context.Background(), PasswordReset{
+			TokenHash: hashToken(fmt.Sprint("old-", i)),
+			UserID:    u.ID,
+			CreatedAt: at,
+			ExpiresAt: at.Add(resetTTL),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forgot("alice@example.com")
+	if n := m.count(); n != 0 {
+		t.Errorf("%d emails past the daily limit, want 0", n)
+	}
+}
+
+func TestForgotPasswordUnverifiedEmail(t *testing.T) {
+	m := setupAuth(t)
+	bob := newUser(t, "bob", "")
+	if err := repo.UpdateEmail(context.Background(), bob.ID, "bob@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// adresse inconnue ou non vérifiée : même réponse, aucun email
+	a, b := forgot("nobody@example.com"), forgot("bob@example.com")
+	if a.StatusCode != b.StatusCode || m.count() != 0 {
+		t.Errorf("unknown/unverified address: status %d/%d, %d emails", a.StatusCode, b.StatusCode, m.count())
+	}
+}
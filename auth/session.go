@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sessions côté serveur : le cookie "session" porte un identifiant
+// aléatoire signé (HMAC-SHA256) ; le store ne garde que le hash SHA-256 de
+// l'identifiant, associé au compte et à une date d'expiration.
+const (
+	sessionCookie = "session"
+	legacyCookie  = "user" // ancien cookie en clair, effacé à la connexion
+)
+
+// Session : une ligne de la table sessions.
+type Session struct {
+	TokenHash string // hex(SHA-256(identifiant))
+	UserID    int
+	Username  string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SessionStore is the persistence abstraction for login sessions.
+type SessionStore interface {
+	// CreateSession stores a new session.
+	CreateSession(ctx context.Context, s Session) error
+	// GetSession returns the unexpired session with this hash (nil if none).
+	GetSession(ctx context.Context, tokenHash string) (*Session, error)
+	// DeleteSession removes one session (logout, rotation).
+	DeleteSession(ctx context.Context, tokenHash string) error
+	// DeleteUserSessions removes every session of a user (account deletion).
+	DeleteUserSessions(ctx context.Context, userID int) error
+	// DeleteExpired purges the sessions expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// sessionStore : implémentation choisie dans Init (MySQL si dispo, sinon mémoire)
+var sessionStore SessionStore
+
+var (
+	sessionKey []byte                // clé HMAC des cookies (SESSION_SECRET)
+	sessionTTL = 30 * 24 * time.Hour // durée de vie (SESSION_TTL)
+)
+
+// initSessions lit SESSION_SECRET et SESSION_TTL. Sans secret, une clé
+// aléatoire est tirée : les sessions ne survivent pas au redémarrage.
+func initSessions() {
+	if secret := os.Getenv("SESSION_SECRET"); secret != "" {
+		sessionKey = []byte(secret)
+	} else {
+		sessionKey = make([]byte, 32)
+		if _, err := rand.Read(sessionKey); err != nil {
+			log.Fatalf("auth: session key: %v", err)
+		}
+		log.Println("auth: SESSION_SECRET not set — sessions will not survive a restart")
+	}
+	if v := os.Getenv("SESSION_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			sessionTTL = d
+		} else {
+			log.Printf("auth: invalid SESSION_TTL %q — using %s", v, sessionTTL)
+		}
+	}
+}
+
+// randomToken : 256 bits aléatoires encodés en base64 URL.
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken : clé de stockage d'un identifiant de session (ou d'un jeton
+// envoyé par email).
+func hashToken(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// signToken : valeur du cookie, "identifiant.signature".
+func signToken(id string) string {
+	mac := hmac.New(sha256.New, sessionKey)
+	mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyToken renvoie l'identifiant d'une valeur de cookie bien signée.
+func verifyToken(value string) (string, bool) {
+	id, sig, ok := strings.Cut(value, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signToken(id)[len(id)+1:]), []byte(sig)) {
+		return "", false
+	}
+	return id, true
+}
+
+// sessionFromRequest : session valide du cookie de la requête (nil sinon).
+func sessionFromRequest(r *http.Request) *Session {
+	if sessionStore == nil {
+		return nil
+	}
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	id, ok := verifyToken(c.Value)
+	if !ok {
+		return nil
+	}
+	s, err := sessionStore.GetSession(r.Context(), hashToken(id))
+	if err != nil {
+		log.Printf("auth: get session: %v", err)
+		return nil
+	}
+	return s
+}
+
+// startSession ouvre une session pour u après une connexion réussie. La
+// session précédente éventuelle est supprimée (rotation de l'identifiant).
+func startSession(w http.ResponseWriter, r *http.Request, u *User) error {
+	ctx := r.Context()
+	if old := sessionFromRequest(r); old != nil {
+		if err := sessionStore.DeleteSession(ctx, old.TokenHash); err != nil {
+			log.Printf("auth: rotate session: %v", err)
+		}
+	}
+	if err := sessionStore.DeleteExpired(ctx, time.Now()); err != nil {
+		log.Printf("auth: purge sessions: %v", err)
+	}
+
+	id, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	s := Session{
+		TokenHash: hashToken(id),
+		UserID:    u.ID,
+		Username:  u.Username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(sessionTTL),
+	}
+	if err := sessionStore.CreateSession(ctx, s); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    signToken(id),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  s.ExpiresAt,
+	})
+	clearCookie(w, legacyCookie)
+	return nil
+}
+
+// endSession supprime la session de la requête et efface le cookie.
+func endSession(w http.ResponseWriter, r *http.Request) {
+	if s := sessionFromRequest(r); s != nil {
+		if err := sessionStore.DeleteSession(r.Context(), s.TokenHash); err != nil {
+			log.Printf("auth: delete session: %v", err)
+		}
+	}
+	clearCookie(w, sessionCookie)
+	clearCookie(w, legacyCookie)
+}
+
+func clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+	})
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{sessions: make(map[string]Session)}
+}
+
+func (m *memorySessionStore) CreateSession(ctx context.Context, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.TokenHash] = s
+	return nil
+}
+
+func (m *memorySessionStore) GetSession(ctx context.Context, tokenHash string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	if !time.Now().Before(s.ExpiresAt) {
+		delete(m.sessions, tokenHash)
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *memorySessionStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, tokenHash)
+	return nil
+}
+
+func (m *memorySessionStore) DeleteUserSessions(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, k)
+		}
+	}
+	return nil
+}
+
+func (m *memorySessionStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, s := range m.sessions {
+		if !now.Before(s.ExpiresAt) {
+			delete(m.sessions, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type mysqlSessionStore struct {
+	db *sql.DB
+}
+
+// NewMySQLSessionStore wraps the connection of a MySQL repository (table sessions).
+func NewMySQLSessionStore(db *sql.DB) SessionStore {
+	return &mysqlSessionStore{db: db}
+}
+
+func (m *mysqlSessionStore) CreateSession(ctx context.Context, s Session) error {
+	_, err := m.db.ExecContext(ctx,
+		"INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		s.TokenHash, s.UserID, s.CreatedAt, s.ExpiresAt,
+	)
+	return err
+}
+
+// GetSession joint users pour renvoyer le pseudo avec la session.
+func (m *mysqlSessionStore) GetSession(ctx context.Context, tokenHash string) (*Session, error) {
+	s := Session{TokenHash: tokenHash}
+	err := m.db.QueryRowContext(ctx, `
+		SELECT s.user_id, u.username, s.created_at, s.expires_at
+		FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > ?`,
+		tokenHash, time.Now(),
+	).Scan(&s.UserID, &s.Username, &s.CreatedAt, &s.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (m *mysqlSessionStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+func (m *mysqlSessionStore) DeleteUserSessions(ctx context.Context, userID int) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = ?", userID)
+	return err
+}
+
+func (m *mysqlSessionStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= ?", now)
+	return err
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSignVerifyToken(t *testing.T) {
+	sessionKey = []byte("test-session-key")
+	signed := signToken("abc")
+	if id, ok := verifyToken(signed); !ok || id != "abc" {
+		t.Fatalf("verifyToken(%q) = %q, %v", signed, id, ok)
+	}
+
+	_, sig, _ := strings.Cut(signed, ".")
+	for _, bad := range []string{
+		"",
+		"abc",                   // sans signature
+		"." + sig,               // identifiant vide
+		"abd." + sig,            // identifiant modifié
+		"abc." + sig[1:],        // signature tronquée
+		"abc." + sig + "x",      // signature allongée
+		"abc.not-the-signature", // signature quelconque
+	} {
+		if id, ok := verifyToken(bad); ok {
+			t.Errorf("verifyToken(%q) accepted (id %q)", bad, id)
+		}
+	}
+
+	sessionKey = []byte("another-key") // clé changée : anciens cookies refusés
+	if _, ok := verifyToken(signed); ok {
+		t.Error("token signed with the old key accepted")
+	}
+}
+
+func TestLoginRotatesSession(t *testing.T) {
+	setupAuth(t)
+	newUser(t, "alice", "")
+
+	first := login(t, "alice", "secret")
+	if got := userOf(first); got != "alice" {
+		t.Fatalf("first session user = %q, want alice", got)
+	}
+	second := login(t, "alice", "secret", first)
+	if second.Value == first.Value {
+		t.Fatal("login kept the same session id")
+	}
+	if got := userOf(first); got != "" {
+		t.Errorf("previous session still valid for %q", got)
+	}
+	if got := userOf(second); got != "alice" {
+		t.Errorf("new session user = %q, want alice", got)
+	}
+}
+
+func TestForgedCookieRejected(t *testing.T) {
+	setupAuth(t)
+	newUser(t, "alice", "")
+
+	// ancien cookie en clair et identifiant signé inconnu du store
+	for _, c := range []*http.Cookie{
+		{Name: legacyCookie, Value: "alice"},
+		{Name: sessionCookie, Value: "alice"},
+		{Name: sessionCookie, Value: signToken("unknown-id")},
+	} {
+		if got := userOf(c); got != "" {
+			t.Errorf("cookie %s=%q logged in as %q", c.Name, c.Value, got)
+		}
+	}
+}
+
+func TestLogoutEndsSession(t *testing.T) {
+	setupAuth(t)
+	newUser(t, "alice", "")
+	c := login(t, "alice", "secret")
+
+	// un GET ne déconnecte pas
+	serve(LogoutHandler, "/logout", nil, []*http.Cookie{c})
+	if userOf(c) != "alice" {
+		t.Fatal("GET /logout ended the session")
+	}
+
+	w := serve(LogoutHandler, "/logout", url.Values{}, []*http.Cookie{c})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("logout status %d", w.Code)
+	}
+	if got := userOf(c); got != "" {
+		t.Errorf("session still valid after logout for %q", got)
+	}
+}
+
+func TestDeleteAccountEndsAllSessions(t *testing.T) {
+	setupAuth(t)
+	newUser(t, "alice", "")
+	newUser(t, "bob", "")
+	phone := login(t, "alice", "secret")
+	laptop := login(t, "alice", "secret")
+	other := login(t, "bob", "secret")
+
+	serve(DeleteAccountHandler, "/delete_account", url.Values{}, []*http.Cookie{laptop})
+	for _, c := range []*http.Cookie{phone, laptop} {
+		if got := userOf(c); got != "" {
+			t.Errorf("session of deleted account still valid for %q", got)
+		}
+	}
+	if got := userOf(other); got != "bob" {
+		t.Errorf("other account's session user = %q, want bob", got)
+	}
+	if u, _ := repo.Authenticate(t.Context(), "alice", "secret"); u != nil {
+		t.Error("deleted account can still log in")
+	}
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// --------- TOTP (RFC 6238) ---------
+
+// Paramètres par défaut des applications d'authentification : HMAC-SHA1,
+// 6 chiffres, pas de 30 secondes.
+const (
+	totpIssuer = "Power4"
+	totpDigits = 6
+	totpPeriod = 30
+	totpSkew   = 1 // pas acceptés avant/après le pas courant (décalage d'horloge)
+)
+
+// base32NoPad : encodage des secrets, sans "=" (format attendu par otpauth://)
+var base32NoPad = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret : 160 bits aléatoires (taille de la sortie SHA-1), en base32.
+func newTOTPSecret() (string, error) {
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base32NoPad.EncodeToString(raw), nil
+}
+
+// totpStep : numéro du pas de temps contenant t.
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// totpCode : code à totpDigits chiffres du pas step (HOTP, RFC 4226).
+func totpCode(secret string, step int64) (string, error) {
+	key, err := base32NoPad.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// troncature dynamique
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, bin%mod), nil
+}
+
+// matchTOTP cherche code parmi les pas voisins de now plus récents que
+// after (anti-rejeu) et renvoie le pas reconnu.
+func matchTOTP(secret, code string, now time.Time, after int64) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	cur := totpStep(now)
+	for step := cur - totpSkew; step <= cur+totpSkew; step++ {
+		if step <= after {
+			continue
+		}
+		want, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(want), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// otpauthURI : URI à encoder en QR code pour l'application
+// d'authentification (format Key Uri de Google Authenticator).
+func otpauthURI(username, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(totpIssuer+":"+username) + "?" + q.Encode()
+}
+
+// --------- CODES DE SECOURS ---------
+
+const recoveryCodeCount = 10
+
+// newRecoveryCodes : recoveryCodeCount codes de 10 caractères base32
+// (50 bits), affichés "abcde-fghij".
+func newRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		c := strings.ToLower(base32NoPad.EncodeToString(raw))[:10]
+		codes[i] = c[:5] + "-" + c[5:]
+	}
+	return codes, nil
+}
+
+// normalizeCode : saisie sans espaces ni tirets, en minuscules (codes de
+// secours tapés à la main, code TOTP copié "123 456").
+func normalizeCode(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '\t':
+			return -1
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// hashRecoveryCode : clé de stockage d'un code de secours.
+func hashRecoveryCode(code string) string {
+	return hashToken(normalizeCode(code))
+}
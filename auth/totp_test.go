@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret : clé SHA-1 des vecteurs de test de la RFC 6238 (annexe B),
+// "12345678901234567890" en base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	// codes à 8 chiffres de la RFC ; totpCode en donne les 6 derniers
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfc6238Secret, totpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.code[len(tt.code)-totpDigits:]; got != want {
+			t.Errorf("T=%d: code %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestMatchTOTPWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	cur := totpStep(now)
+	code := func(step int64) string {
+		c, err := totpCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	for _, d := range []int64{-totpSkew, 0, totpSkew} {
+		if step, ok := matchTOTP(rfc6238Secret, code(cur+d), now, 0); !ok || step != cur+d {
+			t.Errorf("step %+d: matched %d, %v", d, step, ok)
+		}
+	}
+	for _, d := range []int64{-totpSkew - 1, totpSkew + 1} {
+		if _, ok := matchTOTP(rfc6238Secret, code(cur+d), now, 0); ok {
+			t.Errorf("step %+d outside the window accepted", d)
+		}
+	}
+	// pas déjà utilisé, ou antérieur au dernier utilisé : refusé
+	if _, ok := matchTOTP(rfc6238Secret, code(cur), now, cur); ok {
+		t.Error("code of the last used step accepted")
+	}
+	if _, ok := matchTOTP(rfc6238Secret, code(cur-1), now, cur); ok {
+		t.Error("code older than the last used step accepted")
+	}
+	if _, ok := matchTOTP(rfc6238Secret, "12345", now, 0); ok {
+		t.Error("short code accepted")
+	}
+}
+
+func TestSecondFactorSingleUse(t *testing.T) {
+	setupAuth(t)
+	ctx := context.Background()
+	u := newUser(t, "alice", "")
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashRecoveryCode(c)
+	}
+	if err := twoFactorStore.BeginTwoFactor(ctx, u.ID, rfc6238Secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := twoFactorStore.EnableTwoFactor(ctx, u.ID, 0, hashes); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := totpCode(rfc6238Secret, totpStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := checkSecondFactor(ctx, u.ID, code); err != nil || !ok {
+		t.Fatalf("first use of TOTP code: %v, %v", ok, err)
+	}
+	if ok, _ := checkSecondFactor(ctx, u.ID, code); ok {
+		t.Error("TOTP code replayed")
+	}
+
+	// code de secours : saisie libre (majuscules, espaces), une seule fois
+	typed := " " + strings.ToUpper(codes[0][:3]) + " " + codes[0][3:] + " "
+	if ok, err := checkSecondFactor(ctx, u.ID, typed); err != nil || !ok {
+		t.Fatalf("first use of recovery code %q: %v, %v", typed, ok, err)
+	}
+	if ok, _ := checkSecondFactor(ctx, u.ID, codes[0]); ok {
+		t.Error("recovery code used twice")
+	}
+	if ok, _ := checkSecondFactor(ctx, u.ID, "zzzzz-zzzzz"); ok {
+		t.Error("unknown recovery code accepted")
+	}
+}
+
+func TestCodeFailuresLock(t *testing.T) {
+	const userID = 1 << 20 // compteurs globaux : compte propre à ce test
+	now := time.Now()
+	for i := 0; i < maxCodeFailures; i++ {
+		if codeLocked(userID, now) {
+			t.Fatalf("locked after %d failures", i)
+		}
+		recordCodeFailure(userID, now)
+	}
+	if !codeLocked(userID, now) {
+		t.Errorf("not locked after %d failures", maxCodeFailures)
+	}
+	if codeLocked(userID, now.Add(codeFailureWindow)) {
+		t.Error("still locked after the failure window")
+	}
+}
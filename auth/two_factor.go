@@ -0,0 +1,388 @@
+package auth
+
+import (
+	"context"
+	"html/template"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// --------- DOUBLE AUTHENTIFICATION (TOTP) ---------
+
+// TwoFactor : l'état TOTP d'un compte (table user_two_factor).
+type TwoFactor struct {
+	UserID       int
+	Secret       string // base32
+	Enabled      bool   // false : inscription en attente de confirmation
+	LastStep     int64  // dernier pas TOTP accepté (anti-rejeu)
+	RecoveryLeft int    // codes de secours encore utilisables
+}
+
+// TwoFactorStore is the persistence abstraction for TOTP secrets and
+// recovery codes.
+type TwoFactorStore interface {
+	// GetTwoFactor returns the TOTP state of a user (nil if never enrolled).
+	GetTwoFactor(ctx context.Context, userID int) (*TwoFactor, error)
+	// BeginTwoFactor stores a new unconfirmed secret, replacing a pending
+	// one. An enabled secret is left untouched.
+	BeginTwoFactor(ctx context.Context, userID int, secret string) error
+	// EnableTwoFactor confirms the pending secret (step = the code just
+	// checked) and replaces the recovery codes with codeHashes.
+	EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error
+	// UseStep records step as used if it is newer than the last one,
+	// atomically (false: code already used).
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+	// UseRecoveryCode consumes one recovery code (false if unknown or used).
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	// DisableTwoFactor removes the secret and the recovery codes.
+	DisableTwoFactor(ctx context.Context, userID int) error
+}
+
+// twoFactorStore : implémentation choisie dans Init (MySQL si dispo, sinon mémoire)
+var twoFactorStore TwoFactorStore
+
+// twoFactorEnabled : le compte doit-il fournir un second facteur ?
+func twoFactorEnabled(ctx context.Context, userID int) (bool, error) {
+	tf, err := twoFactorStore.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// checkSecondFactor valide code pour userID : code TOTP à 6 chiffres (une
+// seule fois par pas) ou code de secours (une seule fois).
+func checkSecondFactor(ctx context.Context, userID int, code string) (bool, error) {
+	code = normalizeCode(code)
+	tf, err := twoFactorStore.GetTwoFactor(ctx, userID)
+	if err != nil || tf == nil || !tf.Enabled || code == "" {
+		return false, err
+	}
+	if len(code) == totpDigits {
+		step, ok := matchTOTP(tf.Secret, code, time.Now(), tf.LastStep)
+		if !ok {
+			return false, nil
+		}
+		return twoFactorStore.UseStep(ctx, userID, step)
+	}
+	return twoFactorStore.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+}
+
+// --------- CONNEXION : SECOND FACTEUR ---------
+
+// Après le mot de passe, un compte protégé reçoit un cookie "login_2fa"
+// (identifiant signé d'une connexion en attente, gardée en mémoire
+// loginChallengeTTL) au lieu de la session. Les échecs sont comptés par
+// compte : au-delà de maxCodeFailures sur codeFailureWindow, le second
+// facteur est refusé jusqu'à la fin de la fenêtre.
+const (
+	challengeCookie   = "login_2fa"
+	loginChallengeTTL = 5 * time.Minute
+	maxCodeFailures   = 5
+	codeFailureWindow = 15 * time.Minute
+)
+
+type loginChallenge struct {
+	UserID    int
+	Username  string
+	ExpiresAt time.Time
+}
+
+type codeFailures struct {
+	n     int
+	since time.Time
+}
+
+var (
+	challengeMu sync.Mutex
+	challenges  = make(map[string]loginChallenge) // hash de l'identifiant → connexion en attente
+	failedCodes = make(map[int]*codeFailures)     // par compte
+)
+
+// startLoginChallenge met u en attente du second facteur.
+func startLoginChallenge(w http.ResponseWriter, r *http.Request, u *User) error {
+	id, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	challengeMu.Lock()
+	for k, c := range challenges { // purge des connexions abandonnées
+		if !now.Before(c.ExpiresAt) {
+			delete(challenges, k)
+		}
+	}
+	for id, f := range failedCodes {
+		if now.Sub(f.since) >= codeFailureWindow {
+			delete(failedCodes, id)
+		}
+	}
+	challenges[hashToken(id)] = loginChallenge{UserID: u.ID, Username: u.Username, ExpiresAt: now.Add(loginChallengeTTL)}
+	challengeMu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     challengeCookie,
+		Value:    signToken(id),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		Expires:  now.Add(loginChallengeTTL),
+	})
+	return nil
+}
+
+// challengeFromRequest : connexion en attente du cookie (clé "" si aucune).
+func challengeFromRequest(r *http.Request) (string, *loginChallenge) {
+	c, err := r.Cookie(challengeCookie)
+	if err != nil {
+		return "", nil
+	}
+	id, ok := verifyToken(c.Value)
+	if !ok {
+		return "", nil
+	}
+	key := hashToken(id)
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+	lc, ok := challenges[key]
+	if !ok || !time.Now().Before(lc.ExpiresAt) {
+		return "", nil
+	}
+	return key, &lc
+}
+
+// codeLocked : trop d'échecs récents pour ce compte ?
+func codeLocked(userID int, now time.Time) bool {
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+	f := failedCodes[userID]
+	return f != nil && now.Sub(f.since) < codeFailureWindow && f.n >= maxCodeFailures
+}
+
+// recordCodeFailure compte un mauvais code pour userID.
+func recordCodeFailure(userID int, now time.Time) {
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+	f := failedCodes[userID]
+	if f == nil || now.Sub(f.since) >= codeFailureWindow {
+		f = &codeFailures{since: now}
+		failedCodes[userID] = f
+	}
+	f.n++
+}
+
+// LoginTwoFactorHandler : GET = formulaire du code / POST = vérification
+// puis ouverture de la session.
+func LoginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	key, lc := challengeFromRequest(r)
+	if lc == nil {
+		clearCookie(w, challengeCookie)
+		renderForm(w, r, "login.gohtml", "Connexion expirée, recommence.")
+		return
+	}
+	if r.Method != http.MethodPost {
+		renderForm(w, r, "login_two_factor.gohtml", "")
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	if codeLocked(lc.UserID, now) {
+		log.Printf("login_2fa: too many failures for '%s'", lc.Username)
+		renderForm(w, r, "login_two_factor.gohtml", "Trop de codes erronés. Réessaie dans quelques minutes.")
+		return
+	}
+	ok, err := checkSecondFactor(ctx, lc.UserID, r.FormValue("code"))
+	if err != nil {
+		log.Printf("login_2fa: check code for '%s': %v", lc.Username, err)
+	}
+	if !ok {
+		recordCodeFailure(lc.UserID, now)
+		renderForm(w, r, "login_two_factor.gohtml", "Code invalide.")
+		return
+	}
+
+	challengeMu.Lock()
+	delete(challenges, key)
+	delete(failedCodes, lc.UserID)
+	challengeMu.Unlock()
+	clearCookie(w, challengeCookie)
+
+	if err := startSession(w, r, &User{ID: lc.UserID, Username: lc.Username}); err != nil {
+		log.Printf("login_2fa: start session for '%s': %v", lc.Username, err)
+		http.Error(w, "session error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/legacy", http.StatusSeeOther)
+}
+
+// --------- INSCRIPTION DEPUIS LE PROFIL ---------
+
+// twoFactorPage est passé au template two_factor.gohtml : étape de
+// configuration (secret + URI) ou, une fois activée, codes de secours.
+type twoFactorPage struct {
+	Error         string
+	Secret        string
+	URI           template.URL // otpauth:// (à transformer en QR code)
+	RecoveryCodes []string
+	CSRF          string
+}
+
+// profileUser : compte connecté, ou redirection (nil) vers /login.
+func profileUser(w http.ResponseWriter, r *http.Request) *User {
+	username := currentUser(r)
+	if username == "" {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil
+	}
+	u, err := repo.GetByUsername(r.Context(), username)
+	if err != nil || u == nil {
+		log.Printf("two_factor: GetByUsername error for '%s': %v", username, err)
+		http.Error(w, "user lookup error", http.StatusInternalServerError)
+		return nil
+	}
+	return u
+}
+
+// renderTwoFactor affiche la page de configuration (jamais mise en cache :
+// elle contient le secret ou les codes de secours).
+func renderTwoFactor(w http.ResponseWriter, r *http.Request, page twoFactorPage) {
+	page.CSRF = CSRFToken(w, r)
+	w.Header().Set("Cache-Control", "no-store")
+	if err := tpl.ExecuteTemplate(w, "two_factor.gohtml", page); err != nil {
+		log.Printf("two_factor: template error: %v", err)
+		http.Error(w, "template error", http.StatusInternalServerError)
+	}
+}
+
+// TwoFactorSetupHandler : POST /two_factor_setup — tire un nouveau secret
+// (en attente tant qu'un premier code n'a pas été saisi).
+func TwoFactorSetupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+	u := profileUser(w, r)
+	if u == nil {
+		return
+	}
+	ctx := r.Context()
+	if on, err := twoFactorEnabled(ctx, u.ID); err != nil || on {
+		if err != nil {
+			log.Printf("two_factor_setup: lookup for '%s': %v", u.Username, err)
+		}
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	secret, err := newTOTPSecret()
+	if err == nil {
+		err = twoFactorStore.BeginTwoFactor(ctx, u.ID, secret)
+	}
+	if err != nil {
+		log.Printf("two_factor_setup: begin for '%s': %v", u.Username, err)
+		http.Error(w, "two-factor setup error", http.StatusInternalServerError)
+		return
+	}
+	renderTwoFactor(w, r, twoFactorPage{
+		Secret: secret,
+		URI:    template.URL(otpauthURI(u.Username, secret)),
+	})
+}
+
+// TwoFactorEnableHandler : POST /two_factor_enable — confirme le secret
+// avec un premier code, puis affiche (une seule fois) les codes de secours.
+// Les autres sessions du compte sont fermées.
+func TwoFactorEnableHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+	u := profileUser(w, r)
+	if u == nil {
+		return
+	}
+	ctx := r.Context()
+	tf, err := twoFactorStore.GetTwoFactor(ctx, u.ID)
+	if err != nil {
+		log.Printf("two_factor_enable: lookup for '%s': %v", u.Username, err)
+	}
+	if tf == nil || tf.Enabled {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	step, ok := matchTOTP(tf.Secret, normalizeCode(r.FormValue("code")), time.Now(), 0)
+	if !ok {
+		renderTwoFactor(w, r, twoFactorPage{
+			Error:  "Code invalide : vérifie l'heure de ton téléphone et réessaie.",
+			Secret: tf.Secret,
+			URI:    template.URL(otpauthURI(u.Username, tf.Secret)),
+		})
+		return
+	}
+
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		log.Printf("two_factor_enable: recovery codes for '%s': %v", u.Username, err)
+		http.Error(w, "two-factor setup error", http.StatusInternalServerError)
+		return
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashRecoveryCode(c)
+	}
+	if err := twoFactorStore.EnableTwoFactor(ctx, u.ID, step, hashes); err != nil {
+		log.Printf("two_factor_enable: enable for '%s': %v", u.Username, err)
+		http.Error(w, "two-factor setup error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("two_factor: enabled for user %d", u.ID)
+
+	// sessions ouvertes avant l'activation : fermées, sauf celle-ci (rouverte)
+	if err := sessionStore.DeleteUserSessions(ctx, u.ID); err != nil {
+		log.Printf("two_factor_enable: DeleteUserSessions error for '%s': %v", u.Username, err)
+	}
+	if err := startSession(w, r, u); err != nil {
+		log.Printf("two_factor_enable: start session for '%s': %v", u.Username, err)
+	}
+	renderTwoFactor(w, r, twoFactorPage{RecoveryCodes: codes})
+}
+
+// TwoFactorDisableHandler : POST /two_factor_disable — désactive la double
+// authentification, sur présentation d'un code (TOTP ou de secours).
+func TwoFactorDisableHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+	u := profileUser(w, r)
+	if u == nil {
+		return
+	}
+	ctx := r.Context()
+	now := time.Now()
+	if codeLocked(u.ID, now) {
+		http.Redirect(w, r, "/profile?notice=two_factor_locked", http.StatusSeeOther)
+		return
+	}
+	ok, err := checkSecondFactor(ctx, u.ID, r.FormValue("code"))
+	if err != nil {
+		log.Printf("two_factor_disable: check code for '%s': %v", u.Username, err)
+	}
+	if !ok {
+		recordCodeFailure(u.ID, now)
+		http.Redirect(w, r, "/profile?notice=two_factor_bad_code", http.StatusSeeOther)
+		return
+	}
+	if err := twoFactorStore.DisableTwoFactor(ctx, u.ID); err != nil {
+		log.Printf("two_factor_disable: disable for '%s': %v", u.Username, err)
+		http.Error(w, "two-factor disable error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("two_factor: disabled for user %d", u.ID)
+	http.Redirect(w, r, "/profile?notice=two_factor_disabled", http.StatusSeeOther)
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type memoryTwoFactorStore struct {
+	mu    sync.Mutex
+	users map[int]*memoryTwoFactor
+}
+
+type memoryTwoFactor struct {
+	tf    TwoFactor
+	codes map[string]bool // hash → déjà utilisé
+}
+
+func NewMemoryTwoFactorStore() TwoFactorStore {
+	return &memoryTwoFactorStore{users: make(map[int]*memoryTwoFactor)}
+}
+
+func (m *memoryTwoFactorStore) GetTwoFactor(ctx context.Context, userID int) (*TwoFactor, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	tf := mt.tf
+	for _, used := range mt.codes {
+		if !used {
+			tf.RecoveryLeft++
+		}
+	}
+	return &tf, nil
+}
+
+func (m *memoryTwoFactorStore) BeginTwoFactor(ctx context.Context, userID int, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mt, ok := m.users[userID]; ok && mt.tf.Enabled {
+		return nil
+	}
+	m.users[userID] = &memoryTwoFactor{tf: TwoFactor{UserID: userID, Secret: secret}}
+	return nil
+}
+
+func (m *memoryTwoFactorStore) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.users[userID]
+	if !ok || mt.tf.Enabled {
+		return errors.New("no pending two-factor enrollment")
+	}
+	mt.tf.Enabled = true
+	mt.tf.LastStep = step
+	mt.codes = make(map[string]bool, len(codeHashes))
+	for _, h := range codeHashes {
+		mt.codes[h] = false
+	}
+	return nil
+}
+
+func (m *memoryTwoFactorStore) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.users[userID]
+	if !ok || !mt.tf.Enabled || step <= mt.tf.LastStep {
+		return false, nil
+	}
+	mt.tf.LastStep = step
+	return true, nil
+}
+
+func (m *memoryTwoFactorStore) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.users[userID]
+	if !ok || !mt.tf.Enabled {
+		return false, nil
+	}
+	used, known := mt.codes[codeHash]
+	if !known || used {
+		return false, nil
+	}
+	mt.codes[codeHash] = true
+	return true, nil
+}
+
+func (m *memoryTwoFactorStore) DisableTwoFactor(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.users, userID)
+	return nil
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type mysqlTwoFactorStore struct {
+	db *sql.DB
+}
+
+// NewMySQLTwoFactorStore wraps the connection of a MySQL repository
+// (tables user_two_factor and two_factor_recovery_codes).
+func NewMySQLTwoFactorStore(db *sql.DB) TwoFactorStore {
+	return &mysqlTwoFactorStore{db: db}
+}
+
+func (m *mysqlTwoFactorStore) GetTwoFactor(ctx context.Context, userID int) (*TwoFactor, error) {
+	tf := TwoFactor{UserID: userID}
+	err := m.db.QueryRowContext(ctx, `
+		SELECT t.secret, t.enabled_at IS NOT NULL, t.last_step,
+		       (SELECT COUNT(*) FROM two_factor_recovery_codes c
+		        WHERE c.user_id = t.user_id AND c.used_at IS NULL)
+		FROM user_two_factor t WHERE t.user_id = ?`,
+		userID,
+	).Scan(&tf.Secret, &tf.Enabled, &tf.LastStep, &tf.RecoveryLeft)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// BeginTwoFactor : le secret d'une ligne déjà activée n'est pas remplacé.
+func (m *mysqlTwoFactorStore) BeginTwoFactor(ctx context.Context, userID int, secret string) error {
+	_, err := m.db.ExecContext(ctx, `
+		INSERT INTO user_two_factor (user_id, secret, created_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			created_at = IF(enabled_at IS NULL, VALUES(created_at), created_at),
+			secret = IF(enabled_at IS NULL, VALUES(secret), secret)`,
+		userID, secret, time.Now(),
+	)
+	return err
+}
+
+// EnableTwoFactor : activation et nouveaux codes de secours dans une même
+// transaction.
+func (m *mysqlTwoFactorStore) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE user_two_factor SET enabled_at = ?, last_step = ? WHERE user_id = ? AND enabled_at IS NULL",
+		time.Now(), step, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n != 1 {
+		return errors.New("no pending two-factor enrollment")
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM two_factor_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO two_factor_recovery_codes (user_id, code_hash) VALUES (?, ?)",
+			userID, h,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseStep : UPDATE conditionnel, deux connexions ne peuvent pas valider le
+// même code.
+func (m *mysqlTwoFactorStore) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res, err := m.db.ExecContext(ctx,
+		"UPDATE user_two_factor SET last_step = ? WHERE user_id = ? AND enabled_at IS NOT NULL AND last_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (m *mysqlTwoFactorStore) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := m.db.ExecContext(ctx,
+		"UPDATE two_factor_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		time.Now(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (m *mysqlTwoFactorStore) DisableTwoFactor(ctx context.Context, userID int) error {
+	if _, err := m.db.ExecContext(ctx, "DELETE FROM two_factor_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	_, err := m.db.ExecContext(ctx, "DELETE FROM user_two_factor WHERE user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// --------- VÉRIFICATION DE L'EMAIL ---------
+
+const (
+	verifyTTL      = 24 * time.Hour  // validité d'un lien de vérification
+	resendInterval = 2 * time.Minute // délai minimal entre deux envois
+	maxDailySends  = 5               // envois maximum par compte sur 24 h
+)
+
+// errEmailTaken : adresse déjà tenue par un autre compte
+var errEmailTaken = errors.New("email already in use")
+
+// EmailVerification : une ligne de la table email_verifications. Le lien
+// ne vaut que pour l'adresse à laquelle il a été envoyé.
+type EmailVerification struct {
+	TokenHash string
+	UserID    int
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// VerifyStore is the persistence abstraction for email verification tokens.
+type VerifyStore interface {
+	CreateVerification(ctx context.Context, v EmailVerification) error
+	// ConsumeVerification marks the token used and returns it, atomically
+	// (nil if unknown, used or expired).
+	ConsumeVerification(ctx context.Context, tokenHash string, now time.Time) (*EmailVerification, error)
+	// RecentVerifications counts the links sent to a user since since and
+	// returns the time of the latest one (zero if none).
+	RecentVerifications(ctx context.Context, userID int, since time.Time) (count int, last time.Time, err error)
+}
+
+// verifyStore : implémentation choisie dans Init (MySQL si dispo, sinon mémoire)
+var verifyStore VerifyStore
+
+// EmailVerified indique si le compte username a une adresse vérifiée :
+// condition pour jouer en classé et figurer au classement.
+func EmailVerified(ctx context.Context, username string) bool {
+	if repo == nil || username == "" {
+		return false
+	}
+	u, err := repo.GetByUsername(ctx, username)
+	if err != nil {
+		log.Printf("auth: EmailVerified lookup for '%s': %v", username, err)
+	}
+	return u != nil && u.EmailVerified
+}
+
+// claimEmail vérifie que email peut être pris par le compte userID (0 à
+// l'inscription). Une adresse tenue par un compte qui ne l'a pas vérifiée
+// dans les temps (faute de frappe…) est libérée ; une adresse vérifiée ou
+// en attente de vérification reste réservée.
+func claimEmail(ctx context.Context, email string, userID int) error {
+	holder, err := repo.GetByEmail(ctx, email)
+	if err != nil || holder == nil || holder.ID == userID {
+		return err
+	}
+	if holder.EmailVerified {
+		return errEmailTaken
+	}
+	n, _, err := verifyStore.RecentVerifications(ctx, holder.ID, time.Now().Add(-verifyTTL))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errEmailTaken
+	}
+	log.Printf("auth: releasing unverified email of user %d", holder.ID)
+	return repo.UpdateEmail(ctx, holder.ID, "")
+}
+
+// sendVerification envoie un lien de vérification pour l'adresse actuelle de u.
+func sendVerification(ctx context.Context, u *User) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := verifyStore.CreateVerification(ctx, EmailVerification{
+		TokenHash: hashToken(token),
+		UserID:    u.ID,
+		Email:     u.Email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(verifyTTL),
+	}); err != nil {
+		return err
+	}
+
+	link := goBase() + "/verify_email?token=" + url.QueryEscape(token)
+	return mailer.Send(ctx, Message{
+		To:      u.Email,
+		Subject: "Power4 — vérifie ton adresse email",
+		Body: "Bonjour " + u.Username + ",\n\n" +
+			"Pour confirmer ton adresse et débloquer les parties classées, ouvre ce lien (valable 24 heures) :\n" +
+			link + "\n\n" +
+			"Si tu n'as pas créé de compte Power4, ignore simplement ce message.\n",
+	})
+}
+
+// VerifyEmailHandler : GET /verify_email?token=… — lien reçu par email.
+func VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	v, err := verifyStore.ConsumeVerification(ctx, hashToken(r.URL.Query().Get("token")), time.Now())
+	if err != nil {
+		log.Printf("verify_email: ConsumeVerification error: %v", err)
+	}
+	ok := false
+	if v != nil {
+		if ok, err = repo.MarkEmailVerified(ctx, v.UserID, v.Email); err != nil {
+			log.Printf("verify_email: MarkEmailVerified error for user %d: %v", v.UserID, err)
+		}
+	}
+
+	if currentUser(r) != "" {
+		notice := "verify_failed"
+		if ok {
+			notice = "email_verified"
+		}
+		http.Redirect(w, r, "/profile?notice="+notice, http.StatusSeeOther)
+		return
+	}
+	if !ok {
+		renderForm(w, r, "login.gohtml", "Lien de vérification invalide ou expiré.")
+		return
+	}
+	renderInfo(w, r, "login.gohtml", "Adresse email vérifiée. Tu peux te connecter.")
+}
+
+// ResendVerificationHandler : POST /resend_verification — renvoie le lien,
+// au plus une fois toutes les resendInterval et maxDailySends fois par jour.
+func ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	username := currentUser(r)
+	if username == "" {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	ctx := r.Context()
+	u, err := repo.GetByUsername(ctx, username)
+	if err != nil || u == nil {
+		log.Printf("resend_verification: GetByUsername error for '%s': %v", username, err)
+		http.Error(w, "user lookup error", http.StatusInternalServerError)
+		return
+	}
+	if u.Email == "" || u.EmailVerified {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	now := time.Now()
+	n, last, err := verifyStore.RecentVerifications(ctx, u.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		log.Printf("resend_verification: RecentVerifications error for '%s': %v", username, err)
+		http.Error(w, "verification error", http.StatusInternalServerError)
+		return
+	}
+	if n >= maxDailySends || now.Sub(last) < resendInterval {
+		http.Redirect(w, r, "/profile?notice=resend_limited", http.StatusSeeOther)
+		return
+	}
+
+	if err := sendVerification(ctx, u); err != nil {
+		log.Printf("resend_verification: send to '%s': %v", username, err)
+		http.Error(w, "could not send email", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/profile?notice=verification_sent", http.StatusSeeOther)
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryVerifyStore struct {
+	mu    sync.Mutex
+	links map[string]*memoryVerification
+}
+
+type memoryVerification struct {
+	v    EmailVerification
+	used bool // gardé pour la limite d'envois
+}
+
+func NewMemoryVerifyStore() VerifyStore {
+	return &memoryVerifyStore{links: make(map[string]*memoryVerification)}
+}
+
+func (m *memoryVerifyStore) CreateVerification(ctx context.Context, v EmailVerification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.links[v.TokenHash] = &memoryVerification{v: v}
+	return nil
+}
+
+func (m *memoryVerifyStore) ConsumeVerification(ctx context.Context, tokenHash string, now time.Time) (*EmailVerification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mv, ok := m.links[tokenHash]
+	if !ok || mv.used || !now.Before(mv.v.ExpiresAt) {
+		return nil, nil
+	}
+	mv.used = true
+	v := mv.v
+	return &v, nil
+}
+
+func (m *memoryVerifyStore) RecentVerifications(ctx context.Context, userID int, since time.Time) (int, time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var (
+		n    int
+		last time.Time
+	)
+	for _, mv := range m.links {
+		if mv.v.UserID != userID || mv.v.CreatedAt.Before(since) {
+			continue
+		}
+		n++
+		if mv.v.CreatedAt.After(last) {
+			last = mv.v.CreatedAt
+		}
+	}
+	return n, last, nil
+}
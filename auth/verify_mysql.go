@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type mysqlVerifyStore struct {
+	db *sql.DB
+}
+
+// NewMySQLVerifyStore wraps the connection of a MySQL repository (table email_verifications).
+func NewMySQLVerifyStore(db *sql.DB) VerifyStore {
+	return &mysqlVerifyStore{db: db}
+}
+
+func (m *mysqlVerifyStore) CreateVerification(ctx context.Context, v EmailVerification) error {
+	_, err := m.db.ExecContext(ctx,
+		"INSERT INTO email_verifications (token_hash, user_id, email, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		v.TokenHash, v.UserID, v.Email, v.CreatedAt, v.ExpiresAt,
+	)
+	return err
+}
+
+// ConsumeVerification : même principe que ConsumeReset (UPDATE conditionnel).
+func (m *mysqlVerifyStore) ConsumeVerification(ctx context.Context, tokenHash string, now time.Time) (*EmailVerification, error) {
+	res, err := m.db.ExecContext(ctx, `
+		UPDATE email_verifications SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?`,
+		now, tokenHash, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return nil, err
+	}
+	v := EmailVerification{TokenHash: tokenHash}
+	err = m.db.QueryRowContext(ctx,
+		"SELECT user_id, email, created_at, expires_at FROM email_verifications WHERE token_hash = ?",
+		tokenHash,
+	).Scan(&v.UserID, &v.Email, &v.CreatedAt, &v.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (m *mysqlVerifyStore) RecentVerifications(ctx context.Context, userID int, since time.Time) (int, time.Time, error) {
+	var (
+		n    int
+		last sql.NullTime
+	)
+	err := m.db.QueryRowContext(ctx,
+		"SELECT COUNT(*), MAX(created_at) FROM email_verifications WHERE user_id = ? AND created_at >= ?",
+		userID, since,
+	).Scan(&n, &last)
+	return n, last.Time, err
+}
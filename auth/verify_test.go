@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// register crée un compte par le formulaire d'inscription et renvoie le
+// cookie de session.
+func register(t *testing.T, name, email string) *http.Cookie {
+	t.Helper()
+	w := serve(RegisterHandler, "/register", url.Values{
+		"username": {name}, "password": {"secret"}, "email": {email},
+	}, nil)
+	c := sessionCookieOf(w)
+	if c == nil {
+		t.Fatalf("register %s: no session cookie (body %q)", name, w.Body.String())
+	}
+	return c
+}
+
+func TestVerificationTokenSingleUse(t *testing.T) {
+	m := setupAuth(t)
+	register(t, "alice", "alice@example.com")
+	if EmailVerified(context.Background(), "alice") {
+		t.Fatal("email verified before the link was opened")
+	}
+	token := m.lastToken(t)
+	target := "/verify_email?token=" + url.QueryEscape(token)
+
+	if body := serve(VerifyEmailHandler, target, nil, nil).Body.String(); !strings.Contains(body, "Adresse email vérifiée") {
+		t.Fatalf("first use: %q", body)
+	}
+	if !EmailVerified(context.Background(), "alice") {
+		t.Fatal("email not verified after the link was opened")
+	}
+	if body := serve(VerifyEmailHandler, target, nil, nil).Body.String(); !strings.Contains(body, "invalide") {
+		t.Errorf("second use: %q", body)
+	}
+	if body := serve(VerifyEmailHandler, "/verify_email?token=forged", nil, nil).Body.String(); !strings.Contains(body, "invalide") {
+		t.Errorf("unknown token: %q", body)
+	}
+}
+
+func TestVerificationLinkBoundToEmail(t *testing.T) {
+	m := setupAuth(t)
+	register(t, "alice", "old@example.com")
+	token := m.lastToken(t)
+	u, _ := repo.GetByUsername(context.Background(), "alice")
+	if err := repo.UpdateEmail(context.Background(), u.ID, "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// lien envoyé à l'ancienne adresse : ne vérifie pas la nouvelle
+	serve(VerifyEmailHandler, "/verify_email?token="+url.QueryEscape(token), nil, nil)
+	if EmailVerified(context.Background(), "alice") {
+		t.Error("link sent to the old address verified the new one")
+	}
+}
+
+func TestResendVerificationRateLimit(t *testing.T) {
+	m := setupAuth(t)
+	session := register(t, "alice", "alice@example.com")
+	if n := m.count(); n != 1 {
+		t.Fatalf("%d emails at registration, want 1", n)
+	}
+
+	// avant resendInterval : refusé, sans email
+	w := serve(ResendVerificationHandler, "/resend_verification", url.Values{}, []*http.Cookie{session})
+	if loc := w.Header().Get("Location"); !strings.Contains(loc, "resend_limited") {
+		t.Errorf("resend right after registration redirected to %q", loc)
+	}
+	if n := m.count(); n != 1 {
+		t.Errorf("%d emails after a limited resend, want 1", n)
+	}
+}
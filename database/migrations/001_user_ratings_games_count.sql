@@ -0,0 +1,3 @@
+-- Nombre de parties classées par joueur (K provisoire de l'Elo)
+ALTER TABLE `user_ratings`
+  ADD COLUMN `games_count` int(10) UNSIGNED NOT NULL DEFAULT 0 AFTER `rating`;
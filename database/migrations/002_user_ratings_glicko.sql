@@ -0,0 +1,4 @@
+-- Glicko-2 : écart de classement (RD) et volatilité à côté du rating
+ALTER TABLE `user_ratings`
+  ADD COLUMN `rd` double NOT NULL DEFAULT 350 AFTER `games_count`,
+  ADD COLUMN `volatility` double NOT NULL DEFAULT 0.06 AFTER `rd`;
@@ -0,0 +1,11 @@
+-- Sessions de connexion côté serveur (hash SHA-256 de l'identifiant du cookie)
+CREATE TABLE `sessions` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`token_hash`),
+  KEY `ix_sessions_user` (`user_id`),
+  KEY `ix_sessions_expires` (`expires_at`),
+  CONSTRAINT `fk_sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
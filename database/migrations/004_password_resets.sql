@@ -0,0 +1,11 @@
+-- Jetons de réinitialisation du mot de passe (hash SHA-256, usage unique)
+CREATE TABLE `password_resets` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`token_hash`),
+  KEY `ix_password_resets_user` (`user_id`),
+  CONSTRAINT `fk_password_resets_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
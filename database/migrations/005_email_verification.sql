@@ -0,0 +1,22 @@
+-- Vérification des adresses email : date de vérification sur users et
+-- liens envoyés (hash SHA-256, usage unique, gardés pour limiter les envois)
+ALTER TABLE `users`
+  ADD COLUMN `email_verified_at` datetime DEFAULT NULL AFTER `email`;
+
+-- les emails vides deviennent NULL pour ne plus se heurter à uq_users_email
+UPDATE `users` SET `email` = NULL WHERE `email` = '';
+
+-- les comptes existants restent non vérifiés : leur adresse n'a jamais été
+-- confirmée, chacun la vérifie depuis son profil (/resend_verification)
+
+CREATE TABLE `email_verifications` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `email` varchar(255) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`token_hash`),
+  KEY `ix_email_verifications_user` (`user_id`, `created_at`),
+  CONSTRAINT `fk_email_verifications_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,20 @@
+-- Double authentification TOTP (RFC 6238) : secret par compte (actif une
+-- fois confirmé par un premier code) et codes de secours à usage unique
+-- (hash SHA-256)
+CREATE TABLE `user_two_factor` (
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `secret` varchar(64) NOT NULL,
+  `enabled_at` datetime DEFAULT NULL,
+  `last_step` bigint(20) NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `fk_user_two_factor_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `two_factor_recovery_codes` (
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`user_id`, `code_hash`),
+  CONSTRAINT `fk_two_factor_recovery_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+-- Suppression de compte : les parties et coups du joueur sont conservés
+-- (historique de l'adversaire) mais anonymisés, comme player2_id et
+-- winner_id
+ALTER TABLE `games` DROP FOREIGN KEY `fk_games_p1`;
+ALTER TABLE `moves` DROP FOREIGN KEY `fk_moves_player`;
+
+ALTER TABLE `games`
+  MODIFY `player1_id` bigint(20) UNSIGNED DEFAULT NULL,
+  ADD CONSTRAINT `fk_games_p1` FOREIGN KEY (`player1_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
+
+ALTER TABLE `moves`
+  MODIFY `player_id` bigint(20) UNSIGNED DEFAULT NULL,
+  ADD CONSTRAINT `fk_moves_player` FOREIGN KEY (`player_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,14 @@
+-- Issue explicite des parties terminées : winner_id est NULL pour un nul
+-- mais aussi quand le gagnant n'a pas de compte (joueur 2 en hot-seat) ou
+-- l'a supprimé
+ALTER TABLE `games`
+  ADD COLUMN `result` enum('p1','p2','draw') DEFAULT NULL AFTER `winner_id`;
+
+-- parties existantes : un winner_id NULL ne distingue pas le nul d'une
+-- victoire du joueur 2 sans compte ; ces parties sont comptées nulles
+UPDATE `games` SET `result` = CASE
+    WHEN `winner_id` IS NULL THEN 'draw'
+    WHEN `winner_id` = `player1_id` THEN 'p1'
+    ELSE 'p2'
+  END
+WHERE `status` = 'finished';
@@ -23,6 +23,21 @@ SET time_zone = "+00:00";
 
 -- --------------------------------------------------------
 
+--
+-- Structure de la table `email_verifications`
+--
+
+CREATE TABLE `email_verifications` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `email` varchar(255) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
 --
 -- Structure de la table `games`
 --
@@ -33,9 +48,10 @@ CREATE TABLE `games` (
   `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
   `started_at` datetime DEFAULT NULL,
   `finished_at` datetime DEFAULT NULL,
-  `player1_id` bigint(20) UNSIGNED NOT NULL,
+  `player1_id` bigint(20) UNSIGNED DEFAULT NULL,
   `player2_id` bigint(20) UNSIGNED DEFAULT NULL,
   `winner_id` bigint(20) UNSIGNED DEFAULT NULL,
+  `result` enum('p1','p2','draw') DEFAULT NULL,
   `rows_count` tinyint(3) UNSIGNED NOT NULL DEFAULT 6,
   `cols_count` tinyint(3) UNSIGNED NOT NULL DEFAULT 7,
   `connect_n` tinyint(3) UNSIGNED NOT NULL DEFAULT 4,
@@ -53,7 +69,7 @@ CREATE TABLE `moves` (
   `id` bigint(20) UNSIGNED NOT NULL,
   `game_id` bigint(20) UNSIGNED NOT NULL,
   `move_no` int(10) UNSIGNED NOT NULL,
-  `player_id` bigint(20) UNSIGNED NOT NULL,
+  `player_id` bigint(20) UNSIGNED DEFAULT NULL,
   `column_index` tinyint(3) UNSIGNED NOT NULL,
   `row_index` tinyint(3) UNSIGNED NOT NULL,
   `disc_color` enum('R','Y') NOT NULL,
@@ -62,6 +78,45 @@ CREATE TABLE `moves` (
 
 -- --------------------------------------------------------
 
+--
+-- Structure de la table `password_resets`
+--
+
+CREATE TABLE `password_resets` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `used_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Structure de la table `sessions`
+--
+
+CREATE TABLE `sessions` (
+  `token_hash` char(64) NOT NULL,
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Structure de la table `two_factor_recovery_codes`
+--
+
+CREATE TABLE `two_factor_recovery_codes` (
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
 --
 -- Structure de la table `users`
 --
@@ -70,6 +125,7 @@ CREATE TABLE `users` (
   `id` bigint(20) UNSIGNED NOT NULL,
   `username` varchar(32) NOT NULL,
   `email` varchar(255) DEFAULT NULL,
+  `email_verified_at` datetime DEFAULT NULL,
   `password_hash` varchar(255) NOT NULL,
   `avatar_url` varchar(512) DEFAULT NULL,
   `is_admin` tinyint(1) NOT NULL DEFAULT 0,
@@ -94,6 +150,9 @@ INSERT INTO `users` (`id`, `username`, `email`, `password_hash`, `avatar_url`, `
 CREATE TABLE `user_ratings` (
   `user_id` bigint(20) UNSIGNED NOT NULL,
   `rating` int(11) NOT NULL DEFAULT 1200,
+  `games_count` int(10) UNSIGNED NOT NULL DEFAULT 0,
+  `rd` double NOT NULL DEFAULT 350,
+  `volatility` double NOT NULL DEFAULT 0.06,
   `updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp()
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -106,6 +165,20 @@ INSERT INTO `user_ratings` (`user_id`, `rating`, `updated_at`) VALUES
 
 -- --------------------------------------------------------
 
+--
+-- Structure de la table `user_two_factor`
+--
+
+CREATE TABLE `user_two_factor` (
+  `user_id` bigint(20) UNSIGNED NOT NULL,
+  `secret` varchar(64) NOT NULL,
+  `enabled_at` datetime DEFAULT NULL,
+  `last_step` bigint(20) NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
 --
 -- Doublure de structure pour la vue `v_user_ranking`
 -- (Voir ci-dessous la vue réelle)
@@ -129,6 +202,13 @@ CREATE ALGORITHM=UNDEFINED DEFINER=`root`@`localhost` SQL SECURITY DEFINER VIEW
 -- Index pour les tables déchargées
 --
 
+--
+-- Index pour la table `email_verifications`
+--
+ALTER TABLE `email_verifications`
+  ADD PRIMARY KEY (`token_hash`),
+  ADD KEY `ix_email_verifications_user` (`user_id`,`created_at`);
+
 --
 -- Index pour la table `games`
 --
@@ -150,6 +230,27 @@ ALTER TABLE `moves`
   ADD KEY `ix_moves_game` (`game_id`),
   ADD KEY `ix_moves_player` (`player_id`);
 
+--
+-- Index pour la table `password_resets`
+--
+ALTER TABLE `password_resets`
+  ADD PRIMARY KEY (`token_hash`),
+  ADD KEY `ix_password_resets_user` (`user_id`);
+
+--
+-- Index pour la table `sessions`
+--
+ALTER TABLE `sessions`
+  ADD PRIMARY KEY (`token_hash`),
+  ADD KEY `ix_sessions_user` (`user_id`),
+  ADD KEY `ix_sessions_expires` (`expires_at`);
+
+--
+-- Index pour la table `two_factor_recovery_codes`
+--
+ALTER TABLE `two_factor_recovery_codes`
+  ADD PRIMARY KEY (`user_id`,`code_hash`);
+
 --
 -- Index pour la table `users`
 --
@@ -164,6 +265,12 @@ ALTER TABLE `users`
 ALTER TABLE `user_ratings`
   ADD PRIMARY KEY (`user_id`);
 
+--
+-- Index pour la table `user_two_factor`
+--
+ALTER TABLE `user_two_factor`
+  ADD PRIMARY KEY (`user_id`);
+
 --
 -- AUTO_INCREMENT pour les tables déchargées
 --
@@ -190,11 +297,17 @@ ALTER TABLE `users`
 -- Contraintes pour les tables déchargées
 --
 
+--
+-- Contraintes pour la table `email_verifications`
+--
+ALTER TABLE `email_verifications`
+  ADD CONSTRAINT `fk_email_verifications_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
 --
 -- Contraintes pour la table `games`
 --
 ALTER TABLE `games`
-  ADD CONSTRAINT `fk_games_p1` FOREIGN KEY (`player1_id`) REFERENCES `users` (`id`),
+  ADD CONSTRAINT `fk_games_p1` FOREIGN KEY (`player1_id`) REFERENCES `users` (`id`) ON DELETE SET NULL,
   ADD CONSTRAINT `fk_games_p2` FOREIGN KEY (`player2_id`) REFERENCES `users` (`id`) ON DELETE SET NULL,
   ADD CONSTRAINT `fk_games_turn` FOREIGN KEY (`player_to_move`) REFERENCES `users` (`id`) ON DELETE SET NULL,
   ADD CONSTRAINT `fk_games_w` FOREIGN KEY (`winner_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
@@ -204,13 +317,37 @@ ALTER TABLE `games`
 --
 ALTER TABLE `moves`
   ADD CONSTRAINT `fk_moves_game` FOREIGN KEY (`game_id`) REFERENCES `games` (`id`) ON DELETE CASCADE,
-  ADD CONSTRAINT `fk_moves_player` FOREIGN KEY (`player_id`) REFERENCES `users` (`id`);
+  ADD CONSTRAINT `fk_moves_player` FOREIGN KEY (`player_id`) REFERENCES `users` (`id`) ON DELETE SET NULL;
+
+--
+-- Contraintes pour la table `password_resets`
+--
+ALTER TABLE `password_resets`
+  ADD CONSTRAINT `fk_password_resets_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
+--
+-- Contraintes pour la table `sessions`
+--
+ALTER TABLE `sessions`
+  ADD CONSTRAINT `fk_sessions_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
+--
+-- Contraintes pour la table `two_factor_recovery_codes`
+--
+ALTER TABLE `two_factor_recovery_codes`
+  ADD CONSTRAINT `fk_two_factor_recovery_codes_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
 
 --
 -- Contraintes pour la table `user_ratings`
 --
 ALTER TABLE `user_ratings`
   ADD CONSTRAINT `fk_r_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
+--
+-- Contraintes pour la table `user_two_factor`
+--
+ALTER TABLE `user_two_factor`
+  ADD CONSTRAINT `fk_user_two_factor_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
 COMMIT;
 
 /*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;
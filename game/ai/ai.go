@@ -0,0 +1,205 @@
+// Package ai : adversaire artificiel (negamax + élagage alpha-bêta) pour game.Game.
+package ai
+
+import (
+	"math"
+	"strings"
+
+	"power4/game"
+)
+
+// Level : niveau de difficulté du bot, aligné sur les plateaux
+// small (easy) / medium / large (hard). Perfect joue sur small avec le
+// solveur exact (voir solver.go).
+type Level int
+
+const (
+	Easy Level = iota + 1
+	Medium
+	Hard
+	Perfect
+)
+
+// ParseLevel accepte "easy|medium|hard" ou la taille de plateau équivalente.
+func ParseLevel(s string) (Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "easy", "small", "facile":
+		return Easy, true
+	case "medium", "normal", "moyen":
+		return Medium, true
+	case "hard", "large", "difficile":
+		return Hard, true
+	case "perfect", "parfait":
+		return Perfect, true
+	}
+	return 0, false
+}
+
+func (l Level) String() string {
+	switch l {
+	case Easy:
+		return "easy"
+	case Hard:
+		return "hard"
+	case Perfect:
+		return "perfect"
+	default:
+		return "medium"
+	}
+}
+
+// Depth : profondeur de recherche (en demi-coups) associée au niveau
+// (pour Perfect, quand le solveur ne conclut pas).
+func (l Level) Depth() int {
+	switch l {
+	case Easy:
+		return 2
+	case Hard, Perfect:
+		return 7
+	default:
+		return 4
+	}
+}
+
+// winScore : score d'une victoire ; on retranche la profondeur pour préférer
+// les victoires rapides et les défaites lentes.
+const winScore = 1_000_000
+
+// perfectBudget : nœuds accordés au solveur par coup du bot Perfect ; en
+// début de partie la résolution dépasse ce budget et le bot cherche à
+// profondeur fixe.
+const perfectBudget = 4_000_000
+
+// BestMove renvoie la meilleure colonne pour le joueur courant au niveau l,
+// ou -1 si la partie est terminée. Aux niveaux Medium et Hard, la
+// bibliothèque d'ouvertures (voir book.go) est consultée avant toute
+// recherche ; Perfect s'en passe, ses coups n'étant pas prouvés exacts.
+func BestMove(g *game.Game, l Level) int {
+	if l != Easy && l != Perfect {
+		if col, ok := bookMove(g); ok {
+			return col
+		}
+	}
+	if l == Perfect {
+		if sol, err := Solve(g, perfectBudget); err == nil {
+			return sol.Best
+		}
+	}
+	col, _ := Search(g, l.Depth())
+	return col
+}
+
+// Search explore depth demi-coups à partir de g (sans le modifier) et renvoie
+// la meilleure colonne et son score du point de vue du joueur courant.
+// Si les gravités sont mêlées, elle se rabat sur fallbackMove (score 0).
+func Search(g *game.Game, depth int) (col, score int) {
+	p, err := fromGame(g)
+	if err != nil {
+		return fallbackMove(g), 0
+	}
+	if p == nil {
+		return -1, 0
+	}
+	if depth < 1 {
+		depth = 1
+	}
+
+	col, score = -1, math.MinInt32
+	alpha, beta := math.MinInt32+1, math.MaxInt32
+	for _, c := range p.order() {
+		if !p.canPlay(c) {
+			continue
+		}
+		r := p.play(c)
+		var v int
+		if p.wins(r, c) {
+			v = winScore - p.moves
+		} else {
+			v = -p.negamax(depth-1, -beta, -alpha)
+		}
+		p.undo(r, c)
+		if v > score {
+			col, score = c, v
+		}
+		if v > alpha {
+			alpha = v
+		}
+	}
+	return col, score
+}
+
+// negamax : score de la position pour le joueur au trait.
+func (p *position) negamax(depth, alpha, beta int) int {
+	if p.moves == p.rows*p.cols {
+		return 0 // match nul
+	}
+	if depth == 0 {
+		return p.evaluate()
+	}
+
+	// victoire immédiate ?
+	for c := 0; c < p.cols; c++ {
+		if !p.canPlay(c) {
+			continue
+		}
+		r := p.play(c)
+		won := p.wins(r, c)
+		p.undo(r, c)
+		if won {
+			return winScore - p.moves - 1
+		}
+	}
+
+	best := math.MinInt32 + 1
+	for _, c := range p.order() {
+		if !p.canPlay(c) {
+			continue
+		}
+		r := p.play(c)
+		v := -p.negamax(depth-1, -beta, -alpha)
+		p.undo(r, c)
+		if v > best {
+			best = v
+		}
+		if v > alpha {
+			alpha = v
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
+
+// fallbackMove : coup sans recherche, pour les positions que position ne
+// sait pas représenter (gravités mêlées). Gagne tout de suite si possible,
+// sinon pare la victoire immédiate adverse, sinon joue la colonne la plus
+// centrale qui n'offre pas la victoire à l'adversaire. Travaille sur des
+// copies : g n'est pas modifiée.
+func fallbackMove(g *game.Game) int {
+	me := g.CurrentPlayer
+	if win := threats(g, me); len(win) > 0 {
+		return win[0]
+	}
+	if block := threats(g, 3-me); len(block) > 0 {
+		return block[0]
+	}
+	valid := make(map[int]bool)
+	for _, c := range g.ValidMoves() {
+		valid[c] = true
+	}
+	best := -1
+	for _, c := range centerOrder(g.Cols) {
+		t := g.Clone()
+		if !valid[c] || !t.Drop(c) {
+			continue
+		}
+		if best < 0 {
+			best = c // à défaut, un coup jouable
+		}
+		if len(threats(t, 3-me)) == 0 {
+			return c
+		}
+	}
+	return best
+}
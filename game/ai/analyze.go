@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"math"
+
+	"power4/game"
+)
+
+// Issues d'un coup analysé
+const (
+	OutcomeWin     = "win"
+	OutcomeLoss    = "loss"
+	OutcomeDraw    = "draw"
+	OutcomeUnknown = "unknown" // au-delà de l'horizon : seul Score compte
+)
+
+// ColumnEval : évaluation d'une colonne jouable, du point de vue du joueur
+// au trait.
+type ColumnEval struct {
+	Col     int    `json:"col"`
+	Outcome string `json:"outcome"`
+	// Plies : demi-coups jusqu'à la fin de partie, ce coup compris
+	// (win / loss / draw uniquement)
+	Plies int `json:"plies,omitempty"`
+	// Score : score heuristique (OutcomeUnknown) ; positif si le coup est
+	// favorable au joueur au trait
+	Score int `json:"score"`
+}
+
+// Analysis : analyse d'une position.
+type Analysis struct {
+	Player  int          `json:"player"` // joueur au trait (0 si la partie est finie)
+	Depth   int          `json:"depth"`  // horizon de recherche (demi-coups)
+	Columns []ColumnEval `json:"columns"`
+	// Threats : colonnes où chaque joueur (P1, P2) gagnerait en y jouant
+	// tout de suite
+	Threats [2][]int `json:"threats"`
+}
+
+// Analyze évalue chaque colonne jouable de g à depth demi-coups. Elle
+// travaille sur une copie (game.Game.Clone) : g n'est jamais modifiée.
+// Chaque colonne est cherchée avec une fenêtre complète pour que son score
+// soit exact, et non une simple borne comme dans Search.
+func Analyze(g *game.Game, depth int) Analysis {
+	g = g.Clone()
+	if depth < 1 {
+		depth = 1
+	}
+	a := Analysis{Depth: depth, Columns: []ColumnEval{}}
+	a.Threats[0] = threats(g, game.P1)
+	a.Threats[1] = threats(g, game.P2)
+
+	p, err := fromGame(g)
+	if err != nil {
+		return shallowAnalysis(g, a)
+	}
+	if p == nil {
+		return a
+	}
+	a.Player = int(p.player)
+
+	for c := 0; c < p.cols; c++ {
+		if !p.canPlay(c) {
+			continue
+		}
+		r := p.play(c)
+		var v int
+		switch {
+		case p.wins(r, c):
+			v = winScore - p.moves
+		default:
+			v = -p.negamax(depth-1, math.MinInt32+1, math.MaxInt32)
+		}
+		// recherche exhaustive si l'horizon couvre toutes les cases restantes
+		exhaustive := depth-1 >= p.rows*p.cols-p.moves
+		p.undo(r, c)
+		e := evalOf(c, v, g.MoveCount, exhaustive)
+		if e.Outcome == OutcomeDraw {
+			e.Plies = p.rows*p.cols - g.MoveCount // nul : plateau plein
+		}
+		a.Columns = append(a.Columns, e)
+	}
+	return a
+}
+
+// evalOf traduit le score v d'une colonne : les scores de victoire valent
+// winScore moins le nombre de coups joués en fin de partie.
+func evalOf(col, v, moves int, exhaustive bool) ColumnEval {
+	e := ColumnEval{Col: col, Outcome: OutcomeUnknown, Score: v}
+	switch {
+	case v > winScore/2:
+		e.Outcome, e.Plies, e.Score = OutcomeWin, winScore-v-moves, 0
+	case v < -winScore/2:
+		e.Outcome, e.Plies, e.Score = OutcomeLoss, winScore+v-moves, 0
+	case exhaustive && v == 0:
+		e.Outcome = OutcomeDraw
+	}
+	return e
+}
+
+// threats : colonnes où p gagne immédiatement, testées sur des copies de g
+// (quel que soit le joueur au trait).
+func threats(g *game.Game, p int) []int {
+	out := []int{}
+	if g.Winner != 0 {
+		return out
+	}
+	for _, c := range g.ValidMoves() {
+		t := g.Clone()
+		t.CurrentPlayer = p
+		if t.Drop(c) && t.Winner == p {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// shallowAnalysis : analyse à un coup de profondeur, sur des copies de g,
+// quand la recherche est impossible (gravités mêlées) : victoire
+// immédiate, coup qui laisse gagner l'adversaire, ou inconnu.
+func shallowAnalysis(g *game.Game, a Analysis) Analysis {
+	a.Depth = 1
+	a.Player = g.CurrentPlayer
+	for _, c := range g.ValidMoves() {
+		t := g.Clone()
+		if !t.Drop(c) {
+			continue
+		}
+		e := ColumnEval{Col: c, Outcome: OutcomeUnknown}
+		switch {
+		case t.Winner == a.Player:
+			e.Outcome, e.Plies = OutcomeWin, 1
+		case t.Winner == -1:
+			e.Outcome, e.Plies = OutcomeDraw, 1
+		case len(threats(t, 3-a.Player)) > 0:
+			e.Outcome, e.Plies = OutcomeLoss, 2
+		}
+		a.Columns = append(a.Columns, e)
+	}
+	return a
+}
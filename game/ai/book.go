@@ -0,0 +1,258 @@
+package ai
+
+// Bibliothèque d'ouvertures : meilleur coup précalculé (source/bookgen)
+// pour chaque position des premiers demi-coups d'une taille de plateau.
+// Une position et sa symétrique gauche-droite partagent une entrée : on
+// range la position de plus petite clé (Bitboard.Key) et on retourne la
+// colonne au besoin. Les coups viennent de la recherche heuristique quand
+// le solveur ne conclut pas dans son budget : ils ne sont pas garantis
+// optimaux, et le niveau Perfect n'utilise pas la bibliothèque.
+//
+// Format binaire (petit-boutiste) :
+//
+//	"P4OB" | version u8 | rows u8 | cols u8 | connectN u8 | depth u8 | n u32
+//	n × (clé u64 | colonne u8), triées par clé croissante
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"power4/game"
+)
+
+const (
+	bookMagic   = "P4OB"
+	bookVersion = 1
+)
+
+// ErrBadBook : fichier de bibliothèque illisible
+var ErrBadBook = errors.New("ai: invalid opening book")
+
+// Book : bibliothèque d'ouvertures d'une taille de plateau.
+type Book struct {
+	Rows, Cols, ConnectN int
+	Depth                int // positions d'au plus Depth jetons
+
+	keys []uint64 // clés canoniques triées
+	cols []uint8  // meilleur coup de la position canonique
+}
+
+// BookFileName : nom du fichier de bibliothèque d'une taille de plateau.
+func BookFileName(rows, cols int) string {
+	return fmt.Sprintf("book_%dx%d.bin", rows, cols)
+}
+
+// Len : nombre de positions de la bibliothèque.
+func (bk *Book) Len() int { return len(bk.keys) }
+
+// canonical : clé de la position ou de sa symétrique (la plus petite), et
+// si c'est la symétrique.
+func canonical(b *game.Bitboard) (key uint64, mirrored bool) {
+	k, mk := b.Key(), b.Mirror().Key()
+	if mk < k {
+		return mk, true
+	}
+	return k, false
+}
+
+// Lookup renvoie le coup de la bibliothèque pour g, s'il y en a un.
+func (bk *Book) Lookup(g *game.Game) (int, bool) {
+	if g.Rows != bk.Rows || g.Cols != bk.Cols || g.ConnectN != bk.ConnectN ||
+		g.Winner != 0 || g.MoveCount > bk.Depth {
+		return -1, false
+	}
+	b, err := game.BitboardFrom(g)
+	if err != nil {
+		return -1, false
+	}
+	key, mirrored := canonical(b)
+	i := sort.Search(len(bk.keys), func(i int) bool { return bk.keys[i] >= key })
+	if i == len(bk.keys) || bk.keys[i] != key {
+		return -1, false
+	}
+	col := int(bk.cols[i])
+	if mirrored {
+		col = bk.Cols - 1 - col
+	}
+	if !b.CanPlay(col) {
+		return -1, false
+	}
+	return col, true
+}
+
+// WriteTo écrit la bibliothèque au format binaire.
+func (bk *Book) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	hdr := []byte(bookMagic)
+	hdr = append(hdr, bookVersion, uint8(bk.Rows), uint8(bk.Cols), uint8(bk.ConnectN), uint8(bk.Depth))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(len(bk.keys)))
+	bw.Write(hdr)
+	var entry [9]byte
+	for i, k := range bk.keys {
+		binary.LittleEndian.PutUint64(entry[:8], k)
+		entry[8] = bk.cols[i]
+		bw.Write(entry[:])
+	}
+	n := int64(len(hdr) + 9*len(bk.keys))
+	return n, bw.Flush()
+}
+
+// ReadBook lit une bibliothèque écrite par WriteTo.
+func ReadBook(r io.Reader) (*Book, error) {
+	br := bufio.NewReader(r)
+	var hdr [13]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadBook, err)
+	}
+	if string(hdr[:4]) != bookMagic || hdr[4] != bookVersion {
+		return nil, fmt.Errorf("%w: bad header", ErrBadBook)
+	}
+	bk := &Book{Rows: int(hdr[5]), Cols: int(hdr[6]), ConnectN: int(hdr[7]), Depth: int(hdr[8])}
+	n := int(binary.LittleEndian.Uint32(hdr[9:]))
+	bk.keys = make([]uint64, n)
+	bk.cols = make([]uint8, n)
+	var entry [9]byte
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(br, entry[:]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadBook, err)
+		}
+		bk.keys[i] = binary.LittleEndian.Uint64(entry[:8])
+		bk.cols[i] = entry[8]
+		if i > 0 && bk.keys[i] <= bk.keys[i-1] || int(bk.cols[i]) >= bk.Cols {
+			return nil, fmt.Errorf("%w: entry %d", ErrBadBook, i)
+		}
+	}
+	return bk, nil
+}
+
+// GenerateBook énumère les positions non terminales d'au plus depth jetons
+// (une par paire de symétriques) et retient pour chacune le coup choisi par
+// eval, lancé sur workers goroutines. progress (si non nil) est appelé
+// après chaque position évaluée.
+func GenerateBook(rows, cols, connectN, depth int, eval func(g *game.Game) int, workers int, progress func(done, total int)) (*Book, error) {
+	root, err := game.NewBitboard(rows, cols, connectN)
+	if err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	// parcours en largeur, une position canonique par clé
+	positions := map[uint64]*game.Bitboard{}
+	level := []*game.Bitboard{root}
+	for d := 0; d <= depth && len(level) > 0; d++ {
+		var next []*game.Bitboard
+		for _, b := range level {
+			key, mirrored := canonical(b)
+			if _, seen := positions[key]; seen {
+				continue
+			}
+			if mirrored {
+				b = b.Mirror()
+			}
+			positions[key] = b
+			if d == depth {
+				continue
+			}
+			for c := 0; c < cols; c++ {
+				if !b.CanPlay(c) {
+					continue
+				}
+				child := *b
+				child.Play(c)
+				if child.IsWin(b.Player()) || child.Full() {
+					continue // partie terminée : aucun coup à prévoir
+				}
+				next = append(next, &child)
+			}
+		}
+		level = next
+	}
+
+	bk := &Book{Rows: rows, Cols: cols, ConnectN: connectN, Depth: depth}
+	for k := range positions {
+		bk.keys = append(bk.keys, k)
+	}
+	sort.Slice(bk.keys, func(i, j int) bool { return bk.keys[i] < bk.keys[j] })
+	bk.cols = make([]uint8, len(bk.keys))
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+		jobs = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				bk.cols[i] = uint8(eval(positions[bk.keys[i]].ToGame()))
+				if progress != nil {
+					mu.Lock()
+					done++
+					progress(done, len(bk.keys))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range bk.keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return bk, nil
+}
+
+// bibliothèques chargées, par taille de plateau
+var (
+	booksMu sync.RWMutex
+	books   = map[[3]int]*Book{}
+)
+
+// LoadBooks charge les fichiers book_RxC.bin présents dans dir et renvoie
+// le nombre de bibliothèques chargées.
+func LoadBooks(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "book_*.bin"))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return n, err
+		}
+		bk, err := ReadBook(f)
+		f.Close()
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", name, err)
+		}
+		booksMu.Lock()
+		books[[3]int{bk.Rows, bk.Cols, bk.ConnectN}] = bk
+		booksMu.Unlock()
+		n++
+	}
+	return n, nil
+}
+
+// bookMove : coup de la bibliothèque chargée pour g, s'il y en a un.
+func bookMove(g *game.Game) (int, bool) {
+	booksMu.RLock()
+	bk := books[[3]int{g.Rows, g.Cols, g.ConnectN}]
+	booksMu.RUnlock()
+	if bk == nil {
+		return -1, false
+	}
+	return bk.Lookup(g)
+}
@@ -0,0 +1,187 @@
+package ai
+
+import "power4/game"
+
+// position : copie légère du plateau utilisée pendant la recherche
+// (jouer/annuler sans allocation ni verrou).
+type position struct {
+	rows, cols, n int
+	inverted      bool
+	cells         []int8 // r*cols + c
+	height        []int  // nombre de jetons par colonne
+	player        int8   // joueur au trait
+	moves         int
+	cols0         []int // colonnes triées du centre vers les bords
+}
+
+// fromGame copie l'état de g ; nil si la partie est terminée. Les
+// hauteurs de colonnes supposent une seule gravité : si des jetons sont
+// « en l'air » pour la gravité courante (/gravity basculée en cours de
+// partie), renvoie game.ErrMixedGravity, comme game.BitboardFrom.
+func fromGame(g *game.Game) (*position, error) {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if g.Winner != 0 {
+		return nil, nil
+	}
+	p := &position{
+		rows:     g.Rows,
+		cols:     g.Cols,
+		n:        g.ConnectN,
+		inverted: g.InvertedGravity,
+		cells:    make([]int8, g.Rows*g.Cols),
+		height:   make([]int, g.Cols),
+		player:   int8(g.CurrentPlayer),
+		moves:    g.MoveCount,
+	}
+	discs := 0
+	for r := 0; r < g.Rows; r++ {
+		for c := 0; c < g.Cols; c++ {
+			if g.Board[r][c] != game.Empty {
+				discs++
+			}
+		}
+	}
+	stacked := 0 // jetons empilés depuis le bord de la gravité courante
+	for c := 0; c < g.Cols; c++ {
+		for h := 0; h < g.Rows; h++ {
+			r := g.Rows - 1 - h
+			if p.inverted {
+				r = h
+			}
+			v := g.Board[r][c]
+			if v == game.Empty {
+				break
+			}
+			p.cells[r*p.cols+c] = int8(v)
+			p.height[c]++
+			stacked++
+		}
+	}
+	if stacked != discs {
+		return nil, game.ErrMixedGravity
+	}
+	p.cols0 = centerOrder(p.cols)
+	return p, nil
+}
+
+// centerOrder : colonnes du centre vers les bords (meilleur élagage).
+func centerOrder(cols int) []int {
+	out := make([]int, 0, cols)
+	for i := 0; i < cols; i++ {
+		d := (i + 1) / 2
+		if i%2 == 1 {
+			d = -d
+		}
+		out = append(out, (cols-1)/2+d)
+	}
+	// plateau de largeur paire : on rattrape la colonne manquante
+	if cols%2 == 0 {
+		out[len(out)-1] = cols - 1
+	}
+	return out
+}
+
+func (p *position) order() []int { return p.cols0 }
+
+func (p *position) canPlay(c int) bool { return p.height[c] < p.rows }
+
+// play pose le jeton du joueur au trait dans c et renvoie la ligne occupée.
+func (p *position) play(c int) int {
+	r := p.rows - 1 - p.height[c]
+	if p.inverted {
+		r = p.height[c]
+	}
+	p.cells[r*p.cols+c] = p.player
+	p.height[c]++
+	p.moves++
+	p.player = 3 - p.player
+	return r
+}
+
+func (p *position) undo(r, c int) {
+	p.cells[r*p.cols+c] = game.Empty
+	p.height[c]--
+	p.moves--
+	p.player = 3 - p.player
+}
+
+// wins indique si le jeton en (r, c) complète un alignement.
+func (p *position) wins(r, c int) bool {
+	who := p.cells[r*p.cols+c]
+	for _, d := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+		n := 1 + p.count(r, c, d[0], d[1], who) + p.count(r, c, -d[0], -d[1], who)
+		if n >= p.n {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *position) count(r, c, dr, dc int, who int8) int {
+	n := 0
+	for i, j := r+dr, c+dc; i >= 0 && i < p.rows && j >= 0 && j < p.cols; i, j = i+dr, j+dc {
+		if p.cells[i*p.cols+j] != who {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// evaluate : score heuristique pour le joueur au trait. Chaque fenêtre de n
+// cases ne contenant les jetons que d'un seul joueur rapporte d'autant plus
+// qu'elle est remplie ; la colonne centrale est légèrement favorisée.
+func (p *position) evaluate() int {
+	me, score := p.player, 0
+	for r := 0; r < p.rows; r++ {
+		for c := 0; c < p.cols; c++ {
+			for _, d := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+				er, ec := r+d[0]*(p.n-1), c+d[1]*(p.n-1)
+				if er < 0 || er >= p.rows || ec < 0 || ec >= p.cols {
+					continue
+				}
+				mine, theirs := 0, 0
+				for k := 0; k < p.n; k++ {
+					switch p.cells[(r+d[0]*k)*p.cols+c+d[1]*k] {
+					case me:
+						mine++
+					case 3 - me:
+						theirs++
+					}
+				}
+				switch {
+				case theirs == 0 && mine > 0:
+					score += windowWeight(mine, p.n)
+				case mine == 0 && theirs > 0:
+					score -= windowWeight(theirs, p.n)
+				}
+			}
+		}
+	}
+	mid := p.cols / 2
+	for r := 0; r < p.rows; r++ {
+		switch p.cells[r*p.cols+mid] {
+		case me:
+			score += 3
+		case 3 - me:
+			score -= 3
+		}
+	}
+	return score
+}
+
+// windowWeight : poids d'une fenêtre contenant k jetons d'un même joueur.
+func windowWeight(k, n int) int {
+	switch n - k {
+	case 0:
+		return 10_000
+	case 1:
+		return 50
+	case 2:
+		return 5
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,389 @@
+package ai
+
+// Solveur exact du Puissance 4 standard (6x7, 4 à aligner), d'après
+// l'approche de P. Pons : bitboard, recherche negamax alpha-bêta en fenêtre
+// nulle avec resserrement itératif de l'intervalle de score, table de
+// transposition, ordre des coups par nombre de menaces créées et élagage
+// des coups perdants.
+//
+// Score d'une position, du point de vue du joueur au trait : positif s'il
+// gagne (d'autant plus qu'il gagne tôt), 0 pour un nul, négatif s'il perd.
+// Gagner avec son jeton posé au coup n° m (1 à 42) vaut (43 - m) / 2,
+// arrondi à l'entier inférieur : 21 pour le coup le plus rapide.
+
+import (
+	"errors"
+	"math/bits"
+	"sync"
+
+	"power4/game"
+)
+
+const (
+	solverRows  = 6
+	solverCols  = 7
+	solverCells = solverRows * solverCols
+
+	minSolverScore = -(solverCells)/2 + 3
+	maxSolverScore = (solverCells+1)/2 - 3
+)
+
+var (
+	// ErrUnsolvable : position hors du solveur (autre plateau que 6x7
+	// puissance 4, gravités mélangées ou partie terminée).
+	ErrUnsolvable = errors.New("ai: position not supported by the 6x7 solver")
+	// ErrBudget : budget de nœuds épuisé avant la fin de la résolution.
+	ErrBudget = errors.New("ai: solver node budget exhausted")
+)
+
+// masques du plateau : une colonne occupe solverRows+1 bits (sentinelle en haut)
+var (
+	bottomMask = func() uint64 {
+		var m uint64
+		for c := 0; c < solverCols; c++ {
+			m |= 1 << uint(c*(solverRows+1))
+		}
+		return m
+	}()
+	boardMask = bottomMask * (1<<solverRows - 1)
+)
+
+func topMaskCol(c int) uint64    { return 1 << uint(solverRows-1+c*(solverRows+1)) }
+func bottomMaskCol(c int) uint64 { return 1 << uint(c*(solverRows+1)) }
+func columnMask(c int) uint64    { return (1<<solverRows - 1) << uint(c*(solverRows+1)) }
+
+// spos : position 6x7 ; cur = jetons du joueur au trait, mask = cases occupées.
+type spos struct {
+	cur, mask uint64
+	moves     int
+}
+
+// sposFrom convertit g (gravité normale ou inversée, la position est la
+// même au miroir vertical près, ce qui ne change pas les alignements).
+func sposFrom(g *game.Game) (spos, error) {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	var p spos
+	if g.Rows != solverRows || g.Cols != solverCols || g.ConnectN != 4 || g.Winner != 0 {
+		return p, ErrUnsolvable
+	}
+	n := 0
+	for c := 0; c < solverCols; c++ {
+		for h := 0; h < solverRows; h++ {
+			r := solverRows - 1 - h
+			if g.InvertedGravity {
+				r = h
+			}
+			v := g.Board[r][c]
+			if v == game.Empty {
+				break
+			}
+			bit := uint64(1) << uint(c*(solverRows+1)+h)
+			p.mask |= bit
+			if v == g.CurrentPlayer {
+				p.cur |= bit
+			}
+			n++
+		}
+	}
+	// jetons « en l'air » : coups joués avec l'autre gravité
+	if n != g.MoveCount {
+		return p, ErrUnsolvable
+	}
+	p.moves = n
+	return p, nil
+}
+
+func (p spos) canPlay(c int) bool { return p.mask&topMaskCol(c) == 0 }
+
+// play joue le coup move (un bit de possible()).
+func (p *spos) play(move uint64) {
+	p.cur ^= p.mask
+	p.mask |= move
+	p.moves++
+}
+
+func (p spos) playCol(c int) spos {
+	p.play((p.mask + bottomMaskCol(c)) & columnMask(c))
+	return p
+}
+
+func (p spos) key() uint64 { return p.cur + p.mask }
+
+func (p spos) possible() uint64 { return (p.mask + bottomMask) & boardMask }
+
+func (p spos) winningPosition() uint64 { return winningCells(p.cur, p.mask) }
+
+func (p spos) opponentWinningPosition() uint64 { return winningCells(p.cur^p.mask, p.mask) }
+
+func (p spos) canWinNext() bool { return p.winningPosition()&p.possible() != 0 }
+
+func (p spos) isWinningMove(c int) bool {
+	return p.winningPosition()&p.possible()&columnMask(c) != 0
+}
+
+// possibleNonLosingMoves : coups jouables qui ne donnent pas une victoire
+// immédiate à l'adversaire (0 si tous perdent).
+func (p spos) possibleNonLosingMoves() uint64 {
+	possible := p.possible()
+	opp := p.opponentWinningPosition()
+	if forced := possible & opp; forced != 0 {
+		if forced&(forced-1) != 0 {
+			return 0 // deux menaces à parer
+		}
+		possible = forced
+	}
+	return possible &^ (opp >> 1) // ne pas jouer sous une menace adverse
+}
+
+// moveScore : nombre de cases gagnantes après le coup (ordre des coups).
+func (p spos) moveScore(move uint64) int {
+	return bits.OnesCount64(winningCells(p.cur|move, p.mask))
+}
+
+// winningCells : cases vides où position compléterait un alignement de 4.
+func winningCells(position, mask uint64) uint64 {
+	// vertical
+	r := (position << 1) & (position << 2) & (position << 3)
+
+	for _, s := range [3]uint{solverRows + 1, solverRows, solverRows + 2} { // horizontal, diagonales
+		q := (position << s) & (position << (2 * s))
+		r |= q & (position << (3 * s))
+		r |= q & (position >> s)
+		q = (position >> s) & (position >> (2 * s))
+		r |= q & (position << s)
+		r |= q & (position >> (3 * s))
+	}
+	return r & (boardMask ^ mask)
+}
+
+// transTable : table de transposition (bornes supérieures). La taille est un
+// nombre premier : avec key < 2^49, les 32 bits de poids faible de la clé
+// suffisent à l'identifier dans sa case.
+type transTable struct {
+	keys []uint32
+	vals []int8
+}
+
+const ttSize = 8388593
+
+func newTransTable() *transTable {
+	return &transTable{keys: make([]uint32, ttSize), vals: make([]int8, ttSize)}
+}
+
+func (t *transTable) put(key uint64, v int8) {
+	i := key % ttSize
+	t.keys[i], t.vals[i] = uint32(key), v
+}
+
+func (t *transTable) get(key uint64) int8 {
+	i := key % ttSize
+	if t.keys[i] == uint32(key) {
+		return t.vals[i]
+	}
+	return 0
+}
+
+// colOrder : colonnes explorées du centre vers les bords
+var colOrder = centerOrder(solverCols)
+
+// Solver : solveur 6x7 et sa table de transposition, réutilisée d'une
+// résolution à l'autre. Un Solver ne sert qu'une recherche à la fois.
+type Solver struct {
+	tt    *transTable
+	nodes uint64
+	limit uint64 // 0 = illimité
+}
+
+// NewSolver alloue un solveur (table de transposition d'environ 40 Mo).
+func NewSolver() *Solver { return &Solver{tt: newTransTable()} }
+
+// Nodes : nœuds explorés par la dernière résolution.
+func (s *Solver) Nodes() uint64 { return s.nodes }
+
+// Solve renvoie le score exact de g pour le joueur au trait, en explorant au
+// plus limit nœuds (0 = sans limite).
+func (s *Solver) Solve(g *game.Game, limit uint64) (int, error) {
+	p, err := sposFrom(g)
+	if err != nil {
+		return 0, err
+	}
+	s.nodes, s.limit = 0, limit
+	return s.solve(p)
+}
+
+func (s *Solver) solve(p spos) (int, error) {
+	if p.canWinNext() {
+		return (solverCells + 1 - p.moves) / 2, nil
+	}
+	lo, hi := -(solverCells-p.moves)/2, (solverCells+1-p.moves)/2
+	// recherches en fenêtre nulle : l'intervalle [lo, hi] se resserre
+	// jusqu'au score exact (approfondissement itératif sur le score)
+	for lo < hi {
+		med := lo + (hi-lo)/2
+		if med <= 0 && lo/2 < med {
+			med = lo / 2
+		} else if med >= 0 && hi/2 > med {
+			med = hi / 2
+		}
+		r, ok := s.negamax(p, med, med+1)
+		if !ok {
+			return 0, ErrBudget
+		}
+		if r <= med {
+			hi = r
+		} else {
+			lo = r
+		}
+	}
+	return lo, nil
+}
+
+// negamax : score de p dans la fenêtre [alpha, beta] ; faux si le budget
+// est épuisé (le score n'a alors aucun sens et rien n'est mémorisé).
+func (s *Solver) negamax(p spos, alpha, beta int) (int, bool) {
+	s.nodes++
+	if s.limit != 0 && s.nodes > s.limit {
+		return 0, false
+	}
+
+	next := p.possibleNonLosingMoves()
+	if next == 0 {
+		return -(solverCells - p.moves) / 2, true // l'adversaire gagne au coup suivant
+	}
+	if p.moves >= solverCells-2 {
+		return 0, true // nul : plus aucun alignement possible
+	}
+
+	if lo := -(solverCells - 2 - p.moves) / 2; alpha < lo {
+		alpha = lo
+		if alpha >= beta {
+			return alpha, true
+		}
+	}
+	hi := (solverCells - 1 - p.moves) / 2
+	if v := s.tt.get(p.key()); v != 0 {
+		hi = int(v) + minSolverScore - 1
+	}
+	if beta > hi {
+		beta = hi
+		if alpha >= beta {
+			return beta, true
+		}
+	}
+
+	// coups triés par nombre de menaces créées (tri par insertion, stable :
+	// à égalité, le centre d'abord)
+	var moves [solverCols]uint64
+	var scores [solverCols]int
+	n := 0
+	for i := solverCols - 1; i >= 0; i-- {
+		move := next & columnMask(colOrder[i])
+		if move == 0 {
+			continue
+		}
+		sc := p.moveScore(move)
+		j := n
+		for ; j > 0 && scores[j-1] > sc; j-- {
+			moves[j], scores[j] = moves[j-1], scores[j-1]
+		}
+		moves[j], scores[j] = move, sc
+		n++
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		child := p
+		child.play(moves[i])
+		v, ok := s.negamax(child, -beta, -alpha)
+		if !ok {
+			return 0, false
+		}
+		v = -v
+		if v >= beta {
+			return v, true
+		}
+		if v > alpha {
+			alpha = v
+		}
+	}
+	s.tt.put(p.key(), int8(alpha-minSolverScore+1))
+	return alpha, true
+}
+
+// Solution : valeur théorique d'une position 6x7 et meilleur coup.
+type Solution struct {
+	Score   int    `json:"score"`   // voir l'en-tête du fichier
+	Outcome string `json:"outcome"` // OutcomeWin | OutcomeLoss | OutcomeDraw
+	Plies   int    `json:"plies"`   // demi-coups jusqu'à la fin en jeu parfait
+	Best    int    `json:"best"`    // meilleure colonne (0-based)
+	// Columns : score de chaque colonne jouable (nil si injouable), pour
+	// mesurer l'erreur d'un coup
+	Columns [solverCols]*int `json:"columns"`
+	Nodes   uint64           `json:"-"` // nœuds explorés, même en cas d'erreur
+}
+
+// SolveColumns résout chaque coup jouable de g et en déduit la valeur de
+// la position et le meilleur coup (le plus central à score égal). limit
+// borne le nombre total de nœuds (0 = sans limite).
+func (s *Solver) SolveColumns(g *game.Game, limit uint64) (Solution, error) {
+	p, err := sposFrom(g)
+	if err != nil {
+		return Solution{}, err
+	}
+	s.nodes, s.limit = 0, limit
+
+	sol := Solution{Best: -1, Score: minSolverScore - 10}
+	for _, c := range colOrder {
+		if !p.canPlay(c) {
+			continue
+		}
+		var v int
+		if p.isWinningMove(c) {
+			v = (solverCells + 1 - p.moves) / 2
+		} else {
+			child := p.playCol(c)
+			cv, err := s.solve(child)
+			if err != nil {
+				return Solution{Nodes: s.nodes}, err
+			}
+			v = -cv
+		}
+		sol.Columns[c] = &v
+		if v > sol.Score {
+			sol.Score, sol.Best = v, c
+		}
+	}
+	sol.Outcome, sol.Plies = scoreOutcome(sol.Score, p.moves)
+	sol.Nodes = s.nodes
+	return sol, nil
+}
+
+// scoreOutcome traduit un score pour une position de moves jetons.
+func scoreOutcome(score, moves int) (string, int) {
+	switch {
+	case score > 0: // le joueur au trait gagne à son (j+1)-ième coup
+		j := (solverCells+1-moves)/2 - score
+		return OutcomeWin, 2*j + 1
+	case score < 0: // l'adversaire gagne à son (j+1)-ième coup
+		j := (solverCells-moves)/2 + score
+		return OutcomeLoss, 2*j + 2
+	}
+	return OutcomeDraw, solverCells - moves
+}
+
+// solver partagé (la table de transposition est coûteuse à allouer)
+var (
+	sharedMu     sync.Mutex
+	sharedSolver *Solver
+)
+
+// Solve résout g avec le solveur partagé, en au plus limit nœuds.
+func Solve(g *game.Game, limit uint64) (Solution, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if sharedSolver == nil {
+		sharedSolver = NewSolver()
+	}
+	return sharedSolver.SolveColumns(g, limit)
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"math/rand"
+	"testing"
+
+	"power4/game"
+)
+
+// fromMoves rejoue une suite de colonnes numérotées à partir de 1 (notation
+// des jeux de tests de P. Pons).
+func fromMoves(t *testing.T, moves string) *game.Game {
+	t.Helper()
+	g := game.New(6, 7, 4)
+	for i, ch := range moves {
+		if g.Winner != 0 || ch < '1' || ch > '7' || !g.Drop(int(ch-'1')) {
+			t.Fatalf("%q: illegal move %c at ply %d", moves, ch, i+1)
+		}
+	}
+	return g
+}
+
+// TestSolveKnownPositions : positions du jeu de tests « fin de partie » de
+// P. Pons (Test_L3_R1) et leur score exact.
+func TestSolveKnownPositions(t *testing.T) {
+	tests := []struct {
+		moves string
+		score int
+	}{
+		{"2252576253462244111563365343671351441", -1},
+		{"23163416124767223154467471272416755633", 0},
+		{"65214673556155731566316327373221417", -1},
+		{"71255763773133525731261364622167124446454", 0},
+	}
+	s := NewSolver()
+	for _, tt := range tests {
+		g := fromMoves(t, tt.moves)
+		got, err := s.Solve(g, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.moves, err)
+		}
+		if got != tt.score {
+			t.Errorf("%s: score %d, want %d", tt.moves, got, tt.score)
+		}
+		sol, err := s.SolveColumns(g, 0)
+		if err != nil {
+			t.Fatalf("%s: SolveColumns: %v", tt.moves, err)
+		}
+		if sol.Score != tt.score || sol.Columns[sol.Best] == nil || *sol.Columns[sol.Best] != tt.score {
+			t.Errorf("%s: SolveColumns score %d (best %d), want %d", tt.moves, sol.Score, sol.Best, tt.score)
+		}
+	}
+}
+
+// TestSolveMatchesSearch : sur des fins de partie aléatoires, la recherche
+// heuristique menée jusqu'au remplissage du plateau est exacte ; le signe
+// de son score doit être celui du solveur.
+func TestSolveMatchesSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := NewSolver()
+	checked := 0
+	for checked < 200 {
+		g := game.New(6, 7, 4)
+		empty := 6 + rng.Intn(5) // 6 à 10 cases libres
+		for g.Winner == 0 && g.MoveCount < 42-empty {
+			valid := g.ValidMoves()
+			g.Drop(valid[rng.Intn(len(valid))])
+		}
+		if g.Winner != 0 {
+			continue
+		}
+		want, err := s.Solve(g, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, score := Search(g, empty)
+		if sign(score) != sign(want) {
+			t.Fatalf("history %v: solver %d, search %d", g.History(), want, score)
+		}
+		checked++
+	}
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,195 @@
+package game
+
+import "errors"
+
+// maxBitCols : nombre maximal de colonnes d'un Bitboard (plateau de 3 lignes)
+const maxBitCols = 16
+
+// ErrBoardTooLarge : le plateau ne tient pas dans 64 bits ((rows+1)*cols > 64).
+var ErrBoardTooLarge = errors.New("game: board too large for a bitboard")
+
+// ErrMixedGravity : jetons « en l'air » pour la gravité courante (coups
+// joués avec l'autre gravité), la position n'a pas d'équivalent Bitboard.
+var ErrMixedGravity = errors.New("game: position mixes both gravities")
+
+// Bitboard : représentation compacte d'une partie pour la recherche rapide.
+// Chaque colonne occupe rows+1 bits (la case supplémentaire reste vide et
+// sert de sentinelle), du bas vers le haut : le bit col*(rows+1)+h est la
+// h-ième case remplie de la colonne. Tient jusqu'en 7x8 et 6x9.
+//
+// En gravité inversée les jetons « tombent » vers le haut : la hauteur h
+// correspond alors à la ligne h du plateau au lieu de rows-1-h. Les
+// alignements étant symétriques, la détection de victoire est la même.
+type Bitboard struct {
+	Rows, Cols, ConnectN int
+	Inverted             bool
+
+	discs  [2]uint64 // jetons de P1 / P2
+	height [maxBitCols]uint8
+	player int // joueur au trait (P1 / P2)
+	moves  int
+}
+
+// NewBitboard crée une position vide ; connectN <= 0 vaut DefaultConnectN.
+func NewBitboard(rows, cols, connectN int) (*Bitboard, error) {
+	if rows <= 0 || cols <= 0 || cols > maxBitCols || (rows+1)*cols > 64 {
+		return nil, ErrBoardTooLarge
+	}
+	return &Bitboard{
+		Rows:     rows,
+		Cols:     cols,
+		ConnectN: normConnectN(connectN),
+		player:   P1,
+	}, nil
+}
+
+// BitboardFrom convertit l'état courant de g.
+func BitboardFrom(g *Game) (*Bitboard, error) {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	b, err := NewBitboard(g.Rows, g.Cols, g.ConnectN)
+	if err != nil {
+		return nil, err
+	}
+	b.Inverted = g.InvertedGravity
+	b.player = g.CurrentPlayer
+	b.moves = g.MoveCount
+	n := 0
+	for c := 0; c < g.Cols; c++ {
+		for h := 0; h < g.Rows; h++ {
+			v := g.Board[b.row(h)][c]
+			if v != P1 && v != P2 {
+				break
+			}
+			b.discs[v-1] |= b.bit(c, h)
+			b.height[c]++
+			n++
+		}
+	}
+	if n != g.MoveCount {
+		return nil, ErrMixedGravity
+	}
+	return b, nil
+}
+
+// ToGame reconstruit un game.Game équivalent (vainqueur compris).
+func (b *Bitboard) ToGame() *Game {
+	g := New(b.Rows, b.Cols, b.ConnectN)
+	g.InvertedGravity = b.Inverted
+	for c := 0; c < b.Cols; c++ {
+		for h := 0; h < int(b.height[c]); h++ {
+			if b.discs[0]&b.bit(c, h) != 0 {
+				g.Board[b.row(h)][c] = P1
+			} else {
+				g.Board[b.row(h)][c] = P2
+			}
+		}
+	}
+	g.MoveCount = b.moves
+	g.CurrentPlayer = b.player
+	switch {
+	case b.IsWin(P1):
+		g.Winner, g.CurrentPlayer = P1, P1
+		g.findWinLines(P1)
+	case b.IsWin(P2):
+		g.Winner, g.CurrentPlayer = P2, P2
+		g.findWinLines(P2)
+	case b.Full():
+		g.Winner = -1
+	}
+	return g
+}
+
+// Mirror renvoie la position symétrique (colonnes inversées de gauche à
+// droite), de même valeur que b au miroir près des coups.
+func (b *Bitboard) Mirror() *Bitboard {
+	m := *b
+	m.discs = [2]uint64{}
+	h := uint(b.Rows + 1)
+	col := uint64(1)<<h - 1
+	for c := 0; c < b.Cols; c++ {
+		mc := b.Cols - 1 - c
+		for p := range b.discs {
+			m.discs[p] |= (b.discs[p] >> (uint(c) * h) & col) << (uint(mc) * h)
+		}
+		m.height[mc] = b.height[c]
+	}
+	return &m
+}
+
+// row : ligne du plateau correspondant à la hauteur h dans une colonne.
+func (b *Bitboard) row(h int) int {
+	if b.Inverted {
+		return h
+	}
+	return b.Rows - 1 - h
+}
+
+func (b *Bitboard) bit(c, h int) uint64 { return 1 << uint(c*(b.Rows+1)+h) }
+
+// Player renvoie le joueur au trait.
+func (b *Bitboard) Player() int { return b.player }
+
+// Moves renvoie le nombre de jetons posés.
+func (b *Bitboard) Moves() int { return b.moves }
+
+// Full indique si le plateau est plein.
+func (b *Bitboard) Full() bool { return b.moves >= b.Rows*b.Cols }
+
+// CanPlay indique si la colonne col peut encore recevoir un jeton.
+func (b *Bitboard) CanPlay(col int) bool {
+	return col >= 0 && col < b.Cols && int(b.height[col]) < b.Rows
+}
+
+// Play pose le jeton du joueur au trait dans col et renvoie la ligne (au sens
+// de Game.Board) où il s'arrête. La colonne doit être jouable (CanPlay).
+func (b *Bitboard) Play(col int) int {
+	h := int(b.height[col])
+	b.discs[b.player-1] |= b.bit(col, h)
+	b.height[col]++
+	b.moves++
+	b.player = 3 - b.player
+	return b.row(h)
+}
+
+// Undo retire le dernier jeton posé dans col.
+func (b *Bitboard) Undo(col int) {
+	b.height[col]--
+	m := ^b.bit(col, int(b.height[col]))
+	b.discs[0] &= m
+	b.discs[1] &= m
+	b.moves--
+	b.player = 3 - b.player
+}
+
+// IsWin indique si p a aligné ConnectN jetons (vérification en O(1) par
+// décalages : horizontal, vertical et les deux diagonales).
+func (b *Bitboard) IsWin(p int) bool {
+	if p != P1 && p != P2 {
+		return false
+	}
+	x := b.discs[p-1]
+	h := uint(b.Rows + 1)
+	return b.aligned(x, 1) || b.aligned(x, h) || b.aligned(x, h-1) || b.aligned(x, h+1)
+}
+
+// aligned : un bit k tel que k, k+s, …, k+(n-1)s sont tous à 1 ?
+func (b *Bitboard) aligned(x uint64, s uint) bool {
+	m := x
+	for i := 1; i < b.ConnectN && m != 0; i++ {
+		m &= x >> (uint(i) * s)
+	}
+	return m != 0
+}
+
+// Key : clé unique de la position (jetons du joueur au trait + masque des
+// cases occupées + une case par colonne), utilisable en table de transposition.
+func (b *Bitboard) Key() uint64 {
+	mask := b.discs[0] | b.discs[1]
+	var bottom uint64
+	for c := 0; c < b.Cols; c++ {
+		bottom |= b.bit(c, 0)
+	}
+	return b.discs[b.player-1] + mask + bottom
+}
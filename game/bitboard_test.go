@@ -0,0 +1,121 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestBitboardMatchesGame joue des parties aléatoires en parallèle sur un
+// Game (Drop/checkEnd) et un Bitboard (Play/IsWin/Full) et vérifie après
+// chaque coup que les deux s'accordent sur la case jouée, la victoire et le
+// nul.
+func TestBitboardMatchesGame(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sizes := []struct{ rows, cols, n int }{
+		{6, 7, 4}, {6, 9, 4}, {7, 8, 4}, {4, 4, 3}, {5, 6, 5}, {3, 16, 3},
+	}
+	for _, sz := range sizes {
+		for i := 0; i < 500; i++ {
+			inverted := i%2 == 1
+			g := New(sz.rows, sz.cols, sz.n)
+			g.InvertedGravity = inverted
+			b, err := NewBitboard(sz.rows, sz.cols, sz.n)
+			if err != nil {
+				t.Fatalf("%dx%d: %v", sz.rows, sz.cols, err)
+			}
+			b.Inverted = inverted
+
+			for g.Winner == 0 {
+				valid := g.ValidMoves()
+				col := valid[rng.Intn(len(valid))]
+				if !b.CanPlay(col) {
+					t.Fatalf("%dx%d: column %d playable in Game, not in Bitboard", sz.rows, sz.cols, col)
+				}
+				p := g.CurrentPlayer
+				if b.Player() != p {
+					t.Fatalf("%dx%d: player %d to move in Game, %d in Bitboard", sz.rows, sz.cols, p, b.Player())
+				}
+				g.Drop(col)
+				row := b.Play(col)
+				h := g.History()
+				if last := h[len(h)-1]; last.Row != row {
+					t.Fatalf("%dx%d inverted=%v: column %d landed on row %d in Game, %d in Bitboard",
+						sz.rows, sz.cols, inverted, col, last.Row, row)
+				}
+
+				if won := b.IsWin(p); won != (g.Winner == p) {
+					t.Fatalf("%dx%d inverted=%v after %v: Game winner %d, Bitboard IsWin(%d)=%v",
+						sz.rows, sz.cols, inverted, h, g.Winner, p, won)
+				}
+				if b.IsWin(3 - p) {
+					t.Fatalf("%dx%d: Bitboard reports a win for the player who did not move", sz.rows, sz.cols)
+				}
+				draw := b.Full() && !b.IsWin(p)
+				if draw != (g.Winner == -1) {
+					t.Fatalf("%dx%d: Game winner %d, Bitboard draw=%v", sz.rows, sz.cols, g.Winner, draw)
+				}
+			}
+
+			// aller-retour : la conversion retrouve le même plateau
+			back, err := BitboardFrom(g)
+			if err != nil {
+				t.Fatalf("%dx%d: BitboardFrom: %v", sz.rows, sz.cols, err)
+			}
+			if back.discs != b.discs {
+				t.Fatalf("%dx%d: BitboardFrom discs %x, played discs %x", sz.rows, sz.cols, back.discs, b.discs)
+			}
+		}
+	}
+}
+
+// BenchmarkPlayUndo : débit de Play/Undo sur une partie 6x7 (un coup + son
+// annulation par itération).
+func BenchmarkPlayUndo(b *testing.B) {
+	bb, err := NewBitboard(6, 7, DefaultConnectN)
+	if err != nil {
+		b.Fatal(err)
+	}
+	// position de milieu de partie, sans alignement
+	for _, c := range []int{3, 3, 2, 4, 4, 2, 1, 5} {
+		bb.Play(c)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := i % 7
+		bb.Play(c)
+		bb.Undo(c)
+	}
+}
+
+// BenchmarkPlayUndoIsWin : Play, test de victoire et Undo, la boucle
+// élémentaire d'une recherche.
+func BenchmarkPlayUndoIsWin(b *testing.B) {
+	bb, err := NewBitboard(6, 7, DefaultConnectN)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, c := range []int{3, 3, 2, 4, 4, 2, 1, 5} {
+		bb.Play(c)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := i % 7
+		p := bb.Player()
+		bb.Play(c)
+		_ = bb.IsWin(p)
+		bb.Undo(c)
+	}
+}
+
+// BenchmarkGameDropUndo : la même boucle sur Game, pour comparaison.
+func BenchmarkGameDropUndo(b *testing.B) {
+	g := New(6, 7, DefaultConnectN)
+	for _, c := range []int{3, 3, 2, 4, 4, 2, 1, 5} {
+		g.Drop(c)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Drop(i % 7)
+		g.Undo()
+	}
+}
@@ -0,0 +1,110 @@
+package game
+
+import "time"
+
+// Clock : pendule d'une partie. Chaque coup est limité à TurnLimit ; si
+// UseBank est actif, chaque joueur dispose en plus d'une réserve de temps
+// (Bank) créditée de Increment après chacun de ses coups (cadence Fischer).
+type Clock struct {
+	TurnLimit time.Duration    // durée max d'un coup (0 = illimitée)
+	UseBank   bool             // réserve de temps activée
+	Bank      [2]time.Duration // réserve restante de P1 / P2
+	Increment time.Duration    // bonus ajouté à la réserve après chaque coup
+
+	player    int       // joueur dont la pendule tourne (0 = arrêtée)
+	turnStart time.Time // début du coup en cours
+}
+
+// NewClock crée une pendule arrêtée ; bank <= 0 désactive la réserve.
+func NewClock(turnLimit, bank, increment time.Duration) *Clock {
+	c := &Clock{TurnLimit: turnLimit, Increment: increment}
+	if bank > 0 {
+		c.UseBank = true
+		c.Bank = [2]time.Duration{bank, bank}
+	}
+	return c
+}
+
+// Running indique si la pendule tourne (et pour quel joueur).
+func (c *Clock) Running() int { return c.player }
+
+// Start lance le décompte du coup de player.
+func (c *Clock) Start(player int, now time.Time) {
+	c.player = player
+	c.turnStart = now
+}
+
+// Stop arrête la pendule en débitant le temps écoulé de la réserve du joueur.
+func (c *Clock) Stop(now time.Time) {
+	if c.player == 0 {
+		return
+	}
+	if c.UseBank {
+		i := c.player - 1
+		c.Bank[i] -= now.Sub(c.turnStart)
+		if c.Bank[i] < 0 {
+			c.Bank[i] = 0
+		}
+	}
+	c.player = 0
+}
+
+// Switch termine le coup en cours (débit + incrément) et lance celui de next.
+func (c *Clock) Switch(next int, now time.Time) {
+	if p := c.player; p != 0 {
+		c.Stop(now)
+		if c.UseBank {
+			c.Bank[p-1] += c.Increment
+		}
+	}
+	c.Start(next, now)
+}
+
+// Deadline renvoie l'instant où le coup en cours expire (zéro si illimité).
+func (c *Clock) Deadline() time.Time {
+	if c.player == 0 {
+		return time.Time{}
+	}
+	limit := c.TurnLimit
+	if c.UseBank {
+		if b := c.Bank[c.player-1]; limit <= 0 || b < limit {
+			limit = b
+		}
+	}
+	if limit <= 0 && !c.UseBank {
+		return time.Time{}
+	}
+	return c.turnStart.Add(limit)
+}
+
+// Remaining renvoie le temps restant pour le coup en cours (-1 si illimité).
+func (c *Clock) Remaining(now time.Time) time.Duration {
+	d := c.Deadline()
+	if d.IsZero() {
+		return -1
+	}
+	if left := d.Sub(now); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// BankLeft renvoie la réserve de player à l'instant now.
+func (c *Clock) BankLeft(player int, now time.Time) time.Duration {
+	if !c.UseBank || player < P1 || player > P2 {
+		return 0
+	}
+	b := c.Bank[player-1]
+	if c.player == player {
+		b -= now.Sub(c.turnStart)
+	}
+	if b < 0 {
+		return 0
+	}
+	return b
+}
+
+// Flagged indique si le joueur dont la pendule tourne a épuisé sa réserve.
+func (c *Clock) Flagged(now time.Time) bool {
+	return c.UseBank && c.player != 0 && c.BankLeft(c.player, now) == 0
+}
@@ -6,24 +6,48 @@ const (
 	Empty = 0 // case vide
 	P1    = 1 // joueur 1
 	P2    = 2 // joueur 2
+
+	DefaultConnectN = 4 // nombre de jetons à aligner par défaut
 )
 
-type Position struct{ R, C int }
+// Position : une case du plateau (ligne, colonne).
+type Position struct {
+	R int `json:"r"`
+	C int `json:"c"`
+}
+
+// Move : un coup joué, tel qu'enregistré dans l'historique.
+type Move struct {
+	Col, Row        int
+	Player          int
+	InvertedGravity bool // gravité en vigueur au moment du coup
+}
 
 type Game struct {
 	Rows, Cols      int
+	ConnectN        int // nombre de jetons alignés pour gagner
 	Board           [][]int
 	CurrentPlayer   int
 	Winner          int
 	MoveCount       int
 	InvertedGravity bool
-	Mu              sync.Mutex
+	// WinLines : lignes gagnantes (au moins ConnectN jetons alignés), une
+	// par direction ; plusieurs si le dernier coup en complète plusieurs.
+	// Vide pour un nul ou un abandon.
+	WinLines [][]Position
+	Mu       sync.Mutex
+
+	history []Move // coups joués, dans l'ordre
+	redo    []Move // coups annulés, rejouables par Redo (le dernier en tête de pile)
+	forfeit int    // joueur qui a perdu par abandon ou au temps (0 = aucun)
 }
 
-func New(rows, cols int) *Game {
+// New crée une partie rows x cols ; connectN <= 0 vaut DefaultConnectN.
+func New(rows, cols, connectN int) *Game {
 	g := &Game{
 		Rows:          rows,
 		Cols:          cols,
+		ConnectN:      normConnectN(connectN),
 		CurrentPlayer: P1,
 		Winner:        0,
 		MoveCount:     0,
@@ -35,19 +59,24 @@ func New(rows, cols int) *Game {
 	return g
 }
 
-func (g *Game) Reset(rows, cols int) {
+func (g *Game) Reset(rows, cols, connectN int) {
 	g.Mu.Lock()
 	defer g.Mu.Unlock()
 
 	g.Rows = rows
 	g.Cols = cols
+	g.ConnectN = normConnectN(connectN)
 	g.Board = make([][]int, rows)
 	for r := range g.Board {
 		g.Board[r] = make([]int, cols)
 	}
 	g.CurrentPlayer = P1
 	g.Winner = 0
+	g.WinLines = nil
 	g.MoveCount = 0
+	g.history = nil
+	g.redo = nil
+	g.forfeit = 0
 }
 
 func (g *Game) Drop(col int) bool {
@@ -62,12 +91,8 @@ func (g *Game) Drop(col int) bool {
 		// Gravité inversée : les jetons tombent vers le haut
 		for r := 0; r < g.Rows; r++ {
 			if g.Board[r][col] == Empty {
-				g.Board[r][col] = g.CurrentPlayer
-				g.MoveCount++
-				g.checkEnd(r, col)
-				if g.Winner == 0 {
-					g.CurrentPlayer = 3 - g.CurrentPlayer
-				}
+				g.place(r, col)
+				g.redo = nil
 				return true
 			}
 		}
@@ -75,12 +100,8 @@ func (g *Game) Drop(col int) bool {
 		// Gravité normale : les jetons tombent vers le bas
 		for r := g.Rows - 1; r >= 0; r-- {
 			if g.Board[r][col] == Empty {
-				g.Board[r][col] = g.CurrentPlayer
-				g.MoveCount++
-				g.checkEnd(r, col)
-				if g.Winner == 0 {
-					g.CurrentPlayer = 3 - g.CurrentPlayer
-				}
+				g.place(r, col)
+				g.redo = nil
 				return true
 			}
 		}
@@ -88,15 +109,155 @@ func (g *Game) Drop(col int) bool {
 	return false
 }
 
+// place pose le jeton du joueur courant en (r, col), l'ajoute à l'historique
+// et passe la main si la partie continue.
+func (g *Game) place(r, col int) {
+	g.Board[r][col] = g.CurrentPlayer
+	g.MoveCount++
+	g.history = append(g.history, Move{Col: col, Row: r, Player: g.CurrentPlayer, InvertedGravity: g.InvertedGravity})
+	g.checkEnd(r, col)
+	if g.Winner == 0 {
+		g.CurrentPlayer = 3 - g.CurrentPlayer
+	}
+}
+
+// Undo annule le dernier coup (la partie reprend si elle était terminée).
+// Renvoie false s'il n'y a rien à annuler, ou si la partie a été perdue par
+// abandon ou au temps : ce n'est pas un coup, et le dernier coup joué n'y
+// est pour rien.
+func (g *Game) Undo() bool {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if len(g.history) == 0 || g.forfeit != 0 {
+		return false
+	}
+	m := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+	g.Board[m.Row][m.Col] = Empty
+	g.MoveCount--
+	g.Winner = 0
+	g.WinLines = nil
+	g.CurrentPlayer = m.Player
+	g.redo = append(g.redo, m)
+	return true
+}
+
+// Redo rejoue le dernier coup annulé, à la case où il avait été joué.
+// Renvoie false s'il n'y a rien à rejouer, ou si le jeton ne tomberait plus
+// sur cette case (gravité changée depuis l'annulation).
+func (g *Game) Redo() bool {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if len(g.redo) == 0 || g.Winner != 0 {
+		return false
+	}
+	m := g.redo[len(g.redo)-1]
+	if g.landing(m.Col, g.InvertedGravity) != m.Row || m.Player != g.CurrentPlayer {
+		return false
+	}
+	g.redo = g.redo[:len(g.redo)-1]
+	g.place(m.Row, m.Col)
+	return true
+}
+
+// CanUndo indique si Undo a un coup à annuler (jamais après un abandon).
+func (g *Game) CanUndo() bool {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+	return len(g.history) > 0 && g.forfeit == 0
+}
+
+// CanRedo indique si un coup annulé peut être rejoué.
+func (g *Game) CanRedo() bool {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+	return len(g.redo) > 0
+}
+
+// History renvoie une copie des coups joués, dans l'ordre.
+func (g *Game) History() []Move {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+	return append([]Move(nil), g.history...)
+}
+
+// Clone renvoie une copie indépendante de g (plateau, historique et lignes
+// gagnantes compris) : la copie peut être jouée sans toucher à g.
+func (g *Game) Clone() *Game {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	c := &Game{
+		Rows:            g.Rows,
+		Cols:            g.Cols,
+		ConnectN:        g.ConnectN,
+		Board:           make([][]int, len(g.Board)),
+		CurrentPlayer:   g.CurrentPlayer,
+		Winner:          g.Winner,
+		MoveCount:       g.MoveCount,
+		InvertedGravity: g.InvertedGravity,
+		history:         append([]Move(nil), g.history...),
+		redo:            append([]Move(nil), g.redo...),
+		forfeit:         g.forfeit,
+	}
+	for r := range g.Board {
+		c.Board[r] = append([]int(nil), g.Board[r]...)
+	}
+	for _, line := range g.WinLines {
+		c.WinLines = append(c.WinLines, append([]Position(nil), line...))
+	}
+	return c
+}
+
+// ValidMoves renvoie les colonnes encore jouables (selon la gravité).
+func (g *Game) ValidMoves() []int {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if g.Winner != 0 {
+		return nil
+	}
+	top := 0
+	if g.InvertedGravity {
+		top = g.Rows - 1
+	}
+	out := make([]int, 0, g.Cols)
+	for c := 0; c < g.Cols; c++ {
+		if g.Board[top][c] == Empty {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Forfeit termine la partie par abandon (ou temps dépassé) de loser. Cette
+// fin de partie est définitive : Undo la refuse.
+func (g *Game) Forfeit(loser int) {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	if g.Winner == 0 && (loser == P1 || loser == P2) {
+		g.Winner = 3 - loser
+		g.forfeit = loser
+	}
+}
+
+// directions d'alignement : horizontal, vertical, diag ↘, diag ↗
+var lineDirs = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
 func (g *Game) checkEnd(r, c int) {
 	p := g.Board[r][c]
 	if p != P1 && p != P2 {
 		return
 	}
-	if g.four(r, c, 1, 0, p) || // horizontal
-		g.four(r, c, 0, 1, p) || // vertical
-		g.four(r, c, 1, 1, p) || // diag ↘
-		g.four(r, c, 1, -1, p) { // diag ↗
+	for _, d := range lineDirs {
+		if line := g.lineThrough(r, c, d[0], d[1], p); line != nil {
+			g.WinLines = append(g.WinLines, line)
+		}
+	}
+	if len(g.WinLines) > 0 {
 		g.Winner = p
 		return
 	}
@@ -105,8 +266,60 @@ func (g *Game) checkEnd(r, c int) {
 	}
 }
 
-func (g *Game) four(r, c, dr, dc, p int) bool {
-	return 1+g.countDir(r, c, dr, dc, p)+g.countDir(r, c, -dr, -dc, p) >= 4
+// lineThrough renvoie les cases de l'alignement de p passant par (r, c)
+// dans la direction (dr, dc), ou nil s'il compte moins de ConnectN jetons.
+func (g *Game) lineThrough(r, c, dr, dc, p int) []Position {
+	back := g.countDir(r, c, -dr, -dc, p)
+	n := 1 + back + g.countDir(r, c, dr, dc, p)
+	if n < g.ConnectN {
+		return nil
+	}
+	line := make([]Position, n)
+	for i := range line {
+		line[i] = Position{R: r + (i-back)*dr, C: c + (i-back)*dc}
+	}
+	return line
+}
+
+// WinningCells renvoie les cases des lignes gagnantes, sans doublon (une
+// case peut appartenir à plusieurs lignes).
+func (g *Game) WinningCells() []Position {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+
+	var out []Position
+	seen := make(map[Position]bool)
+	for _, line := range g.WinLines {
+		for _, pos := range line {
+			if !seen[pos] {
+				seen[pos] = true
+				out = append(out, pos)
+			}
+		}
+	}
+	return out
+}
+
+// findWinLines recalcule WinLines pour p sur tout le plateau (position
+// construite sans passer par Drop).
+func (g *Game) findWinLines(p int) {
+	g.WinLines = nil
+	for r := 0; r < g.Rows; r++ {
+		for c := 0; c < g.Cols; c++ {
+			if g.Board[r][c] != p {
+				continue
+			}
+			for _, d := range lineDirs {
+				pr, pc := r-d[0], c-d[1]
+				if pr >= 0 && pr < g.Rows && pc >= 0 && pc < g.Cols && g.Board[pr][pc] == p {
+					continue // (r, c) n'est pas le début de l'alignement
+				}
+				if line := g.lineThrough(r, c, d[0], d[1], p); line != nil {
+					g.WinLines = append(g.WinLines, line)
+				}
+			}
+		}
+	}
 }
 
 func (g *Game) countDir(r, c, dr, dc, p int) int {
@@ -119,3 +332,10 @@ func (g *Game) countDir(r, c, dr, dc, p int) int {
 	}
 	return n
 }
+
+func normConnectN(n int) int {
+	if n <= 0 {
+		return DefaultConnectN
+	}
+	return n
+}
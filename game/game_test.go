@@ -0,0 +1,42 @@
+package game
+
+import "testing"
+
+// TestRedoAfterGravityChange : un coup annulé ne se rejoue pas si, avec la
+// nouvelle gravité, le jeton tomberait ailleurs (pas de jeton en l'air).
+func TestRedoAfterGravityChange(t *testing.T) {
+	g := New(6, 7, DefaultConnectN)
+	g.Drop(3)
+	g.Drop(3)
+	if !g.Undo() {
+		t.Fatal("undo refused")
+	}
+	g.InvertedGravity = true
+	if g.Redo() {
+		t.Fatalf("redo placed a floating disc: %v", g.Board)
+	}
+	if g.MoveCount != 1 || g.Board[0][3] != Empty {
+		t.Fatalf("redo changed the board: %v", g.Board)
+	}
+
+	g.InvertedGravity = false
+	if !g.Redo() {
+		t.Fatal("redo refused with the original gravity")
+	}
+	if g.Board[4][3] != P2 || g.MoveCount != 2 {
+		t.Fatalf("redo landed elsewhere: %v", g.Board)
+	}
+}
+
+// TestUndoAfterForfeit : un abandon n'est pas un coup, Undo le refuse.
+func TestUndoAfterForfeit(t *testing.T) {
+	g := New(6, 7, DefaultConnectN)
+	g.Drop(3)
+	g.Forfeit(P2)
+	if g.Undo() || g.CanUndo() {
+		t.Fatal("undo accepted after a forfeit")
+	}
+	if g.Winner != P1 || g.MoveCount != 1 {
+		t.Fatalf("winner %d, moves %d", g.Winner, g.MoveCount)
+	}
+}
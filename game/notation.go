@@ -0,0 +1,256 @@
+package game
+
+// Notation texte d'une partie, dans l'esprit du PGN des échecs :
+//
+//	[Player1 "alice"]
+//	[Player2 "bob"]
+//	[Date "2026.10.18"]
+//	[Result "1-0"]
+//
+//	6x9 c4 N 5 5 4 6 I 3 N 3 1-0
+//
+// Des en-têtes [Clé "valeur"] facultatifs, puis la taille du plateau
+// (lignes x colonnes), le nombre de jetons à aligner (cN), la gravité de
+// départ (N normale, I inversée) et les coups : numéros de colonne à partir
+// de 1, séparés par des espaces. Un N ou un I entre deux coups change la
+// gravité des coups suivants. Le résultat final (1-0, 0-1, 1/2-1/2 ou *)
+// est facultatif ; le texte entre accolades est un commentaire.
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Résultats en notation (en-tête Result et fin de la suite de coups)
+const (
+	ResultP1      = "1-0"
+	ResultP2      = "0-1"
+	ResultDraw    = "1/2-1/2"
+	ResultOngoing = "*"
+)
+
+// ordre d'écriture des en-têtes connus ; les autres suivent, triés
+var tagOrder = []string{"Event", "Date", "Player1", "Player2", "Result"}
+
+// Notation : une partie sous forme de notation texte.
+type Notation struct {
+	Rows, Cols int
+	ConnectN   int
+	Moves      []Move            // Col et InvertedGravity suffisent (Row et Player sont recalculés)
+	Tags       map[string]string // métadonnées : Player1, Player2, Date, Result…
+}
+
+// NotationOf décrit g (coups joués et résultat) ; tags peut être nil.
+func NotationOf(g *Game, tags map[string]string) *Notation {
+	n := &Notation{
+		Rows:     g.Rows,
+		Cols:     g.Cols,
+		ConnectN: g.ConnectN,
+		Moves:    g.History(),
+		Tags:     make(map[string]string, len(tags)+1),
+	}
+	for k, v := range tags {
+		n.Tags[k] = v
+	}
+	if _, ok := n.Tags["Result"]; !ok {
+		n.Tags["Result"] = ResultOf(g.Winner)
+	}
+	return n
+}
+
+// ResultOf : résultat en notation pour Game.Winner.
+func ResultOf(winner int) string {
+	switch winner {
+	case P1:
+		return ResultP1
+	case P2:
+		return ResultP2
+	case -1:
+		return ResultDraw
+	}
+	return ResultOngoing
+}
+
+// String écrit la partie en notation.
+func (n *Notation) String() string {
+	var b strings.Builder
+
+	keys := make([]string, 0, len(n.Tags))
+	for k := range n.Tags {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := tagRank(keys[i]), tagRank(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "[%s %s]\n", k, strconv.Quote(n.Tags[k]))
+	}
+	if len(keys) > 0 {
+		b.WriteByte('\n')
+	}
+
+	inverted := len(n.Moves) > 0 && n.Moves[0].InvertedGravity
+	fmt.Fprintf(&b, "%dx%d c%d %s", n.Rows, n.Cols, n.ConnectN, gravityToken(inverted))
+	for _, m := range n.Moves {
+		if m.InvertedGravity != inverted {
+			inverted = m.InvertedGravity
+			b.WriteString(" " + gravityToken(inverted))
+		}
+		fmt.Fprintf(&b, " %d", m.Col+1)
+	}
+	if res := n.Tags["Result"]; res != "" {
+		b.WriteString(" " + res)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func tagRank(k string) int {
+	for i, t := range tagOrder {
+		if k == t {
+			return i
+		}
+	}
+	return len(tagOrder)
+}
+
+func gravityToken(inverted bool) string {
+	if inverted {
+		return "I"
+	}
+	return "N"
+}
+
+// ErrNotation : texte illisible (voir ParseNotation)
+var ErrNotation = errors.New("game: invalid notation")
+
+// ParseNotation lit une partie en notation. Les coups ne sont pas vérifiés :
+// Game les rejoue.
+func ParseNotation(s string) (*Notation, error) {
+	n := &Notation{Tags: make(map[string]string)}
+
+	// en-têtes [Clé "valeur"], un par ligne, avant la suite de coups
+	var body []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if len(body) == 0 && strings.HasPrefix(line, "[") {
+			k, v, err := parseTag(line)
+			if err != nil {
+				return nil, err
+			}
+			n.Tags[k] = v
+			continue
+		}
+		if line != "" {
+			body = append(body, line)
+		}
+	}
+	tokens, err := movetextTokens(strings.Join(body, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) < 3 {
+		return nil, fmt.Errorf("%w: missing board size, connect-N or gravity", ErrNotation)
+	}
+	rows, cols, ok := strings.Cut(tokens[0], "x")
+	if !ok {
+		return nil, fmt.Errorf("%w: bad board size %q", ErrNotation, tokens[0])
+	}
+	if n.Rows, err = strconv.Atoi(rows); err != nil || n.Rows < 1 {
+		return nil, fmt.Errorf("%w: bad board size %q", ErrNotation, tokens[0])
+	}
+	if n.Cols, err = strconv.Atoi(cols); err != nil || n.Cols < 1 {
+		return nil, fmt.Errorf("%w: bad board size %q", ErrNotation, tokens[0])
+	}
+	c, ok := strings.CutPrefix(tokens[1], "c")
+	if n.ConnectN, err = strconv.Atoi(c); !ok || err != nil || n.ConnectN < 2 {
+		return nil, fmt.Errorf("%w: bad connect-N %q", ErrNotation, tokens[1])
+	}
+
+	inverted := false
+	for i, tok := range tokens[2:] {
+		switch tok {
+		case "N", "I":
+			inverted = tok == "I"
+			continue
+		case ResultP1, ResultP2, ResultDraw, ResultOngoing:
+			if i != len(tokens)-3 {
+				return nil, fmt.Errorf("%w: result %q before the last move", ErrNotation, tok)
+			}
+			if _, ok := n.Tags["Result"]; !ok {
+				n.Tags["Result"] = tok
+			}
+			continue
+		}
+		if i == 0 {
+			return nil, fmt.Errorf("%w: missing gravity before %q", ErrNotation, tok)
+		}
+		col, err := strconv.Atoi(tok)
+		if err != nil || col < 1 || col > n.Cols {
+			return nil, fmt.Errorf("%w: bad column %q", ErrNotation, tok)
+		}
+		n.Moves = append(n.Moves, Move{Col: col - 1, InvertedGravity: inverted})
+	}
+	return n, nil
+}
+
+// parseTag lit une ligne [Clé "valeur"].
+func parseTag(line string) (key, value string, err error) {
+	inner, ok := strings.CutSuffix(line[1:], "]")
+	if !ok {
+		return "", "", fmt.Errorf("%w: unterminated header %q", ErrNotation, line)
+	}
+	key, quoted, ok := strings.Cut(strings.TrimSpace(inner), " ")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("%w: bad header %q", ErrNotation, line)
+	}
+	if value, err = strconv.Unquote(strings.TrimSpace(quoted)); err != nil {
+		return "", "", fmt.Errorf("%w: bad header %q", ErrNotation, line)
+	}
+	return key, value, nil
+}
+
+// movetextTokens découpe la suite de coups en retirant les commentaires.
+func movetextTokens(s string) ([]string, error) {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '{':
+			depth++
+		case r == '}':
+			if depth == 0 {
+				return nil, fmt.Errorf("%w: unbalanced comment", ErrNotation)
+			}
+			depth--
+			b.WriteByte(' ')
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced comment", ErrNotation)
+	}
+	return strings.Fields(b.String()), nil
+}
+
+// Game reconstruit la position en rejouant les coups via Drop, avec la
+// gravité de chaque coup.
+func (n *Notation) Game() (*Game, error) {
+	g := New(n.Rows, n.Cols, n.ConnectN)
+	for i, m := range n.Moves {
+		g.InvertedGravity = m.InvertedGravity
+		if !g.Drop(m.Col) {
+			return nil, fmt.Errorf("%w: move %d (column %d) cannot be played", ErrNotation, i+1, m.Col+1)
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,57 @@
+package game
+
+import "fmt"
+
+// Replay rejoue moves sur une partie neuve rows x cols via Drop et appelle
+// step (si non nil) après chaque coup. Chaque coup est joué avec la gravité
+// qu'il indique ; si le jeton n'atterrit pas à la ligne enregistrée, le coup
+// est rejoué avec la gravité inverse (coups relus en base, où seule la ligne
+// est conservée). Renvoie la partie finale, ou une erreur au premier coup
+// impossible à reproduire.
+func Replay(rows, cols, connectN int, moves []Move, step func(g *Game)) (*Game, error) {
+	g := New(rows, cols, connectN)
+	for i, m := range moves {
+		if !g.replayMove(m, m.InvertedGravity) && !g.replayMove(m, !m.InvertedGravity) {
+			return g, fmt.Errorf("game: move %d (col %d, row %d) cannot be replayed", i+1, m.Col, m.Row)
+		}
+		if step != nil {
+			step(g)
+		}
+	}
+	return g, nil
+}
+
+// replayMove joue m.Col avec la gravité inverted si le jeton y atterrit à
+// la ligne m.Row ; sinon la partie reste inchangée.
+func (g *Game) replayMove(m Move, inverted bool) bool {
+	if m.Col < 0 || m.Col >= g.Cols || m.Player != g.CurrentPlayer || g.landingRow(m.Col, inverted) != m.Row {
+		return false
+	}
+	g.InvertedGravity = inverted
+	return g.Drop(m.Col)
+}
+
+// landingRow : ligne où tomberait un jeton joué en col (-1 si pleine).
+func (g *Game) landingRow(col int, inverted bool) int {
+	g.Mu.Lock()
+	defer g.Mu.Unlock()
+	return g.landing(col, inverted)
+}
+
+// landing : landingRow, g.Mu déjà tenu.
+func (g *Game) landing(col int, inverted bool) int {
+	if inverted {
+		for r := 0; r < g.Rows; r++ {
+			if g.Board[r][col] == Empty {
+				return r
+			}
+		}
+	} else {
+		for r := g.Rows - 1; r >= 0; r-- {
+			if g.Board[r][col] == Empty {
+				return r
+			}
+		}
+	}
+	return -1
+}
@@ -4,5 +4,6 @@ go 1.25.0
 
 require (
 	github.com/go-sql-driver/mysql v1.7.0
+	github.com/gorilla/websocket v1.5.3
 	golang.org/x/crypto v0.10.0
-)
\ No newline at end of file
+)
@@ -0,0 +1,83 @@
+// Commande bookgen : génère les bibliothèques d'ouvertures du bot.
+//
+//	go run ./source/bookgen -depth 4 -search 10 -out books
+//
+// Pour chaque taille de plateau (-sizes, par défaut 6x7,6x9,7x8), toutes
+// les positions d'au plus -depth jetons sont évaluées (une seule pour une
+// position et sa symétrique) et le meilleur coup est écrit dans
+// <out>/book_RxC.bin. Sur 6x7, le solveur exact est essayé d'abord avec
+// -solve nœuds ; sinon (ou s'il ne conclut pas) on cherche à -search
+// demi-coups.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"power4/game"
+	"power4/game/ai"
+)
+
+func main() {
+	var (
+		out     = flag.String("out", "books", "output directory")
+		depth   = flag.Int("depth", 4, "book depth (max discs on the board)")
+		search  = flag.Int("search", 10, "search depth (plies) used to pick each move")
+		solve   = flag.Uint64("solve", 2_000_000, "solver node budget per 6x7 position (0 = search only)")
+		sizes   = flag.String("sizes", "6x7,6x9,7x8", "comma-separated board sizes")
+		workers = flag.Int("workers", runtime.NumCPU(), "parallel evaluations")
+	)
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	for _, size := range strings.Split(*sizes, ",") {
+		var rows, cols int
+		if _, err := fmt.Sscanf(strings.TrimSpace(size), "%dx%d", &rows, &cols); err != nil {
+			log.Fatalf("bookgen: bad size %q", size)
+		}
+
+		eval := func(g *game.Game) int {
+			if *solve > 0 && rows == 6 && cols == 7 {
+				if sol, err := ai.Solve(g, *solve); err == nil {
+					return sol.Best
+				}
+			}
+			col, _ := ai.Search(g, *search)
+			return col
+		}
+
+		start := time.Now()
+		last := start
+		bk, err := ai.GenerateBook(rows, cols, game.DefaultConnectN, *depth, eval, *workers, func(done, total int) {
+			if time.Since(last) > 5*time.Second || done == total {
+				last = time.Now()
+				log.Printf("bookgen: %dx%d %d/%d positions", rows, cols, done, total)
+			}
+		})
+		if err != nil {
+			log.Fatalf("bookgen: %dx%d: %v", rows, cols, err)
+		}
+
+		name := filepath.Join(*out, ai.BookFileName(rows, cols))
+		f, err := os.Create(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		n, err := bk.WriteTo(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Fatalf("bookgen: write %s: %v", name, err)
+		}
+		log.Printf("bookgen: %s: %d positions, %d bytes, %s", name, bk.Len(), n, time.Since(start).Round(time.Second))
+	}
+}
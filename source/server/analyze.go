@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"power4/game/ai"
+)
+
+// Profondeur d'analyse (demi-coups) : par défaut, et maximum accepté pour
+// ?depth= (au-delà, une requête coûte plusieurs secondes sur 6x9). Au-delà
+// de 7 colonnes, la profondeur est plafonnée à largeAnalyzeDepth.
+const (
+	defaultAnalyzeDepth = 7
+	maxAnalyzeDepth     = 8
+	largeAnalyzeDepth   = 6
+)
+
+// analyzeCacheSize : positions dont l'analyse est gardée en mémoire.
+const analyzeCacheSize = 256
+
+// analyzeCache : analyses par position, et une seule analyse en cours à la
+// fois pour tout le serveur (comme gradeCache).
+type analyzeCache struct {
+	run sync.Mutex // sérialise les recherches
+
+	mu       sync.Mutex
+	analyses map[string]ai.Analysis
+}
+
+func newAnalyzeCache() *analyzeCache {
+	return &analyzeCache{analyses: make(map[string]ai.Analysis)}
+}
+
+func (c *analyzeCache) get(key string) (ai.Analysis, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a, ok := c.analyses[key]
+	return a, ok
+}
+
+func (c *analyzeCache) put(key string, a ai.Analysis) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.analyses) >= analyzeCacheSize {
+		for k := range c.analyses { // plein : on oublie une position au hasard
+			delete(c.analyses, k)
+			break
+		}
+	}
+	c.analyses[key] = a
+}
+
+// handleAnalyze : GET /games/{id}/analyze[?depth=N] — aide à
+// l'apprentissage : évaluation de chaque colonne jouable (victoire, défaite
+// ou nul en N demi-coups quand la recherche aboutit, score heuristique
+// sinon) et menaces immédiates des deux joueurs. L'analyse porte sur une
+// copie de la partie et ne modifie jamais le plateau. Elle est refusée
+// pendant une partie classée, sauf si ANALYSIS_RANKED=1. Les analyses sont
+// faites une à une et gardées par position (voir analyzeCache).
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request, sess *session) {
+	depth := defaultAnalyzeDepth
+	if v := r.URL.Query().Get("depth"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 || d > maxAnalyzeDepth {
+			http.Error(w, "invalid depth", http.StatusBadRequest)
+			return
+		}
+		depth = d
+	}
+
+	sess.mu.Lock()
+	if sess.ranked && sess.g.Winner == 0 && !s.analyzeRanked {
+		sess.mu.Unlock()
+		http.Error(w, "analysis is disabled in ranked games", http.StatusForbidden)
+		return
+	}
+	g := sess.g.Clone()
+	// la génération change avec la gravité et à chaque nouvelle partie
+	key := gradeKey(sess.ID, g.History()) + "/" + strconv.Itoa(sess.gen) + "/" + strconv.Itoa(depth)
+	sess.mu.Unlock()
+	if g.Cols > 7 && depth > largeAnalyzeDepth {
+		depth = largeAnalyzeDepth
+	}
+
+	// recherche hors verrou : les joueurs continuent pendant l'analyse
+	a, ok := s.analyses.get(key)
+	if !ok {
+		s.analyses.run.Lock()
+		if a, ok = s.analyses.get(key); !ok { // analysée pendant l'attente ?
+			a = ai.Analyze(g, depth)
+			s.analyses.put(key, a)
+		}
+		s.analyses.run.Unlock()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
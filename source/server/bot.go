@@ -0,0 +1,70 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"power4/game"
+	"power4/game/ai"
+)
+
+// botName : pseudo affiché pour le siège tenu par l'ordinateur
+const botName = "🤖 Bot"
+
+// handleVsBot démarre une partie contre l'ordinateur :
+// POST /vsbot level=easy|medium|hard|perfect[&first=bot]. Le plateau suit le
+// niveau (easy et perfect → small, medium → medium, hard → large).
+func (s *Server) handleVsBot(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/legacy", http.StatusSeeOther)
+		return
+	}
+	level, ok := ai.ParseLevel(r.FormValue("level"))
+	if !ok {
+		level = ai.Medium
+	}
+
+	sess := s.games.Create(user)
+
+	sess.mu.Lock()
+	switch level {
+	case ai.Easy, ai.Perfect:
+		sess.g.Reset(6, 7, game.DefaultConnectN)
+		sess.boardTmpl = "board_small"
+	case ai.Hard:
+		sess.g.Reset(7, 8, game.DefaultConnectN)
+		sess.boardTmpl = "board_large"
+	}
+	sess.bot = game.P2
+	if r.FormValue("first") == "bot" {
+		sess.bot = game.P1
+	}
+	sess.Players[0], sess.Players[1] = user, botName
+	if sess.bot == game.P1 {
+		sess.Players[0], sess.Players[1] = botName, user
+	}
+	sess.botLevel = level
+	sess.resetClock()
+	sess.botOpens()
+	sess.mu.Unlock()
+
+	log.Printf("server: %s starts a %s game vs bot (%s)", user, level, sess.ID)
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
+
+// botMove fait jouer l'ordinateur (appelé sous sess.mu).
+func (sess *session) botMove() {
+	col := ai.BestMove(sess.g, sess.botLevel)
+	if col < 0 || !sess.g.Drop(col) {
+		return
+	}
+	sess.afterMove()
+}
+
+// botOpens joue le premier coup si le bot a les jetons du joueur 1
+// (appelé sous sess.mu, après un reset).
+func (sess *session) botOpens() {
+	if sess.bot != 0 && sess.g.MoveCount == 0 && sess.g.CurrentPlayer == sess.bot {
+		sess.botMove()
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"math/rand"
+	"net/url"
+	"strconv"
+	"time"
+
+	"power4/game"
+)
+
+// Politique appliquée quand le temps d'un coup est écoulé
+const (
+	expiryRandom  = "random"  // le serveur joue une colonne au hasard
+	expiryForfeit = "forfeit" // le joueur perd la partie
+)
+
+// clockConfig : cadence d'une partie (conservée d'un reset à l'autre)
+type clockConfig struct {
+	Turn      time.Duration // limite par coup (0 = illimitée)
+	Bank      time.Duration // réserve par joueur (0 = sans réserve)
+	Increment time.Duration // bonus Fischer par coup
+	OnExpiry  string        // expiryRandom | expiryForfeit
+}
+
+// defaultClock : 10s par coup, coup aléatoire si le temps est dépassé
+var defaultClock = clockConfig{Turn: 10 * time.Second, OnExpiry: expiryRandom}
+
+// parseClockConfig lit turn/bank/inc (en secondes) et expiry depuis la query ;
+// les paramètres absents reprennent la valeur de base.
+func parseClockConfig(q url.Values, base clockConfig) (clockConfig, bool) {
+	cfg := base
+	for _, p := range []struct {
+		key string
+		dst *time.Duration
+	}{{"turn", &cfg.Turn}, {"bank", &cfg.Bank}, {"inc", &cfg.Increment}} {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return base, false
+		}
+		*p.dst = time.Duration(n) * time.Second
+	}
+	switch v := q.Get("expiry"); v {
+	case "":
+	case expiryRandom, expiryForfeit:
+		cfg.OnExpiry = v
+	default:
+		return base, false
+	}
+	return cfg, true
+}
+
+// resetClock remet la pendule à zéro et la relance si la partie peut
+// commencer (voir startClock). Appelé sous sess.mu.
+func (sess *session) resetClock() {
+	sess.stopTimer()
+	sess.clock = game.NewClock(sess.clockCfg.Turn, sess.clockCfg.Bank, sess.clockCfg.Increment)
+	sess.startClock(time.Now())
+}
+
+// startClock lance la pendule du premier coup : dès la création pour une
+// partie locale ou contre l'ordinateur, une fois les deux places prises
+// pour une partie en ligne ou classée. Si le bot a le trait, c'est son coup (voir
+// botOpens) qui lancera la pendule du joueur. Appelé sous sess.mu.
+func (sess *session) startClock(now time.Time) {
+	if sess.clock.Running() != 0 || sess.g.Winner != 0 || sess.g.MoveCount != 0 {
+		return
+	}
+	if sess.awaitsOpponent() || (sess.online && sess.Players[0] == "") {
+		return
+	}
+	if sess.bot != 0 && sess.g.CurrentPlayer == sess.bot {
+		return
+	}
+	sess.clock.Start(sess.g.CurrentPlayer, now)
+	sess.armTimer(now)
+}
+
+// afterMove passe la main au joueur suivant, réarme l'expiration du coup et
+// diffuse le coup aux clients connectés. Appelé sous sess.mu après chaque
+// coup joué.
+func (sess *session) afterMove() { sess.nextTurn(true) }
+
+// nextTurn : afterMove, sans diffuser de coup si announce est faux (retour
+// dans l'historique, l'appelant diffuse alors un instantané).
+func (sess *session) nextTurn(announce bool) {
+	sess.persist()
+	sess.stopTimer()
+	now := time.Now()
+	if sess.g.Winner != 0 {
+		sess.clock.Stop(now)
+		if announce {
+			sess.broadcastMove()
+		}
+		return
+	}
+	sess.clock.Switch(sess.g.CurrentPlayer, now)
+	if announce {
+		sess.broadcastMove()
+	}
+
+	// contre l'ordinateur : le bot répond aussitôt
+	if sess.bot != 0 && sess.g.CurrentPlayer == sess.bot {
+		sess.botMove()
+		return
+	}
+
+	sess.armTimer(now)
+}
+
+// armTimer programme l'expiration du coup en cours (appelé sous sess.mu).
+func (sess *session) armTimer(now time.Time) {
+	d := sess.clock.Deadline()
+	if d.IsZero() {
+		return
+	}
+	seq := sess.turnSeq
+	sess.timer = time.AfterFunc(d.Sub(now), func() {
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		if seq == sess.turnSeq {
+			sess.expire(time.Now())
+		}
+	})
+}
+
+// stopTimer annule l'expiration programmée (appelé sous sess.mu).
+func (sess *session) stopTimer() {
+	sess.turnSeq++
+	if sess.timer != nil {
+		sess.timer.Stop()
+		sess.timer = nil
+	}
+}
+
+// expired indique si le coup en cours a dépassé son temps (sous sess.mu).
+func (sess *session) expired(now time.Time) bool {
+	if sess.g.Winner != 0 || sess.clock.Running() == 0 {
+		return false
+	}
+	d := sess.clock.Deadline()
+	return !d.IsZero() && !now.Before(d)
+}
+
+// expire applique la politique de dépassement de temps (sous sess.mu) :
+// réserve épuisée ou mode "forfeit" → défaite, sinon coup aléatoire.
+func (sess *session) expire(now time.Time) {
+	if !sess.expired(now) {
+		return
+	}
+	player := sess.g.CurrentPlayer
+	if sess.clock.Flagged(now) || sess.clockCfg.OnExpiry == expiryForfeit {
+		sess.g.Forfeit(player)
+		sess.persist()
+		sess.stopTimer()
+		sess.clock.Stop(now)
+		sess.live.publish(sess.turnEvents(now)...)
+		return
+	}
+	sess.playRandom()
+}
+
+// playRandom joue une colonne au hasard parmi les colonnes jouables
+// (sous sess.mu). Renvoie false si aucun coup n'est possible.
+func (sess *session) playRandom() bool {
+	avail := sess.g.ValidMoves()
+	if len(avail) == 0 {
+		return false
+	}
+	if !sess.g.Drop(avail[rand.Intn(len(avail))]) {
+		return false
+	}
+	sess.afterMove()
+	return true
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"power4/game"
+	"power4/game/ai"
+)
+
+// gradeBudget : nœuds du solveur par position notée ; gradeRequestBudget :
+// nœuds pour toute une partie. Les positions sont notées de la fin vers le
+// début : celles d'ouverture, trop coûteuses, restent « unknown ».
+const (
+	gradeBudget        = 1_000_000
+	gradeRequestBudget = 5_000_000
+)
+
+// gradeCacheSize : parties terminées dont la notation est gardée en mémoire.
+const gradeCacheSize = 256
+
+// gradeCache : notations des parties terminées (elles ne changent plus), et
+// une seule notation en cours à la fois pour tout le serveur.
+type gradeCache struct {
+	run sync.Mutex // sérialise les notations (le solveur partagé sert aussi au bot)
+
+	mu     sync.Mutex
+	grades map[string][]moveGrade
+}
+
+func newGradeCache() *gradeCache {
+	return &gradeCache{grades: make(map[string][]moveGrade)}
+}
+
+func (c *gradeCache) get(key string) ([]moveGrade, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	g, ok := c.grades[key]
+	return g, ok
+}
+
+func (c *gradeCache) put(key string, g []moveGrade) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.grades) >= gradeCacheSize {
+		for k := range c.grades { // plein : on oublie une partie au hasard
+			delete(c.grades, k)
+			break
+		}
+	}
+	c.grades[key] = g
+}
+
+// gradeKey : la partie et ses coups (une partie en mémoire peut être
+// recommencée sous le même id).
+func gradeKey(id string, moves []game.Move) string {
+	var b strings.Builder
+	b.WriteString(id)
+	b.WriteByte('/')
+	for _, m := range moves {
+		b.WriteString(strconv.Itoa(m.Col))
+	}
+	return b.String()
+}
+
+// Jugements d'un coup par le solveur (voir verdict)
+const (
+	verdictBest       = "best"       // coup de valeur optimale
+	verdictInaccuracy = "inaccuracy" // même issue, mais plus lente (ou défaite plus rapide)
+	verdictMistake    = "mistake"    // victoire → nul, ou nul → défaite
+	verdictBlunder    = "blunder"    // victoire → défaite
+	verdictUnknown    = "unknown"    // position non résolue dans le budget
+)
+
+// moveGrade : jugement d'un coup joué
+type moveGrade struct {
+	Ply     int    `json:"ply"` // n° du coup (1 = premier)
+	Col     int    `json:"col"`
+	Best    int    `json:"best"` // meilleure colonne (-1 si inconnue)
+	Verdict string `json:"verdict"`
+}
+
+// gradable : seul le Puissance 4 standard 6x7 est résolu (ai.Solve).
+func gradable(rows, cols, connectN int) bool {
+	return rows == 6 && cols == 7 && connectN == 4
+}
+
+// handleGrade : GET /games/{id}/grade — note chaque coup d'une partie 6x7
+// avec le solveur exact, pour signaler les erreurs dans le replay.
+func (s *Server) handleGrade(w http.ResponseWriter, r *http.Request, user string) {
+	id := r.PathValue("id")
+	data, moves, status := s.recorded(r.Context(), id, user)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if !gradable(data.Rows, data.Cols, data.ConnectN) {
+		http.Error(w, "grading needs a 6x7 connect-four game", http.StatusBadRequest)
+		return
+	}
+
+	key := gradeKey(id, moves)
+	grades, ok := s.grades.get(key)
+	if !ok {
+		// positions avant chaque coup
+		before := []*game.Game{game.New(data.Rows, data.Cols, data.ConnectN)}
+		final, err := game.Replay(data.Rows, data.Cols, data.ConnectN, moves, func(g *game.Game) {
+			before = append(before, g.Clone())
+		})
+		if err != nil {
+			log.Printf("server: grade game %s: %v", id, err)
+			http.Error(w, "corrupted game record", http.StatusInternalServerError)
+			return
+		}
+
+		s.grades.run.Lock()
+		if grades, ok = s.grades.get(key); !ok { // notée pendant l'attente ?
+			grades = gradeMoves(before, moves)
+			if final.Winner != 0 || data.Winner != 0 { // terminée (ou abandonnée)
+				s.grades.put(key, grades)
+			}
+		}
+		s.grades.run.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grades)
+}
+
+// gradeMoves note les coups en au plus gradeRequestBudget nœuds, en partant
+// du dernier (fins de partie rapides à résoudre). Aucun verrou de partie
+// n'est tenu : before est une copie.
+func gradeMoves(before []*game.Game, moves []game.Move) []moveGrade {
+	grades := make([]moveGrade, len(moves))
+	left := uint64(gradeRequestBudget)
+	for i := len(moves) - 1; i >= 0; i-- {
+		m := moves[i]
+		grades[i] = moveGrade{Ply: i + 1, Col: m.Col, Best: -1, Verdict: verdictUnknown}
+		if left == 0 {
+			continue
+		}
+		sol, err := ai.Solve(before[i], min(left, gradeBudget))
+		left -= min(left, sol.Nodes)
+		if err != nil || sol.Columns[m.Col] == nil {
+			continue
+		}
+		grades[i].Best = sol.Best
+		grades[i].Verdict = verdict(*sol.Columns[m.Col], sol.Score)
+	}
+	return grades
+}
+
+// verdict compare le score du coup joué à celui du meilleur coup.
+func verdict(played, best int) string {
+	switch sign(best) - sign(played) {
+	case 0:
+		if played == best {
+			return verdictBest
+		}
+		return verdictInaccuracy
+	case 1:
+		return verdictMistake
+	default:
+		return verdictBlunder
+	}
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"power4/auth"
+)
+
+// handleUndo annule le dernier coup. Contre le bot, on annule aussi sa
+// réponse pour rendre la main au joueur. Interdit en partie classée ; entre
+// deux joueurs, chacun ne peut reprendre que son propre dernier coup.
+func (s *Server) handleUndo(w http.ResponseWriter, r *http.Request, sess *session) {
+	s.stepHistory(w, r, sess, sess.canUndo, func() bool { return sess.g.Undo() })
+}
+
+// handleRedo rejoue le dernier coup annulé (et la réponse du bot le cas
+// échéant). Entre deux joueurs, seul l'auteur du coup peut le rejouer.
+func (s *Server) handleRedo(w http.ResponseWriter, r *http.Request, sess *session) {
+	s.stepHistory(w, r, sess, sess.canRedo, func() bool { return sess.g.Redo() })
+}
+
+func (s *Server) stepHistory(w http.ResponseWriter, r *http.Request, sess *session, allowed func(seat int) bool, step func() bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.ranked {
+		http.Error(w, "undo/redo disabled in ranked games", http.StatusForbidden)
+		return
+	}
+	if !allowed(sess.seatOf(auth.CurrentUser(r))) {
+		http.Error(w, "you can only take back or replay your own move", http.StatusForbidden)
+		return
+	}
+	if !step() {
+		http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+		return
+	}
+	// contre l'ordinateur : on avance/recule jusqu'au tour du joueur humain
+	for sess.bot != 0 && sess.g.Winner == 0 && sess.g.CurrentPlayer == sess.bot {
+		if !step() {
+			break
+		}
+	}
+	sess.gen++
+	sess.rearmClock(time.Now())
+	sess.broadcastState()
+
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
+
+// twoPlayers : partie entre deux joueurs humains distincts (ni partie
+// locale à un seul compte, ni partie contre le bot). Sous sess.mu.
+func (sess *session) twoPlayers() bool {
+	return sess.bot == 0 && sess.Players[1] != "" && sess.Players[0] != sess.Players[1]
+}
+
+// canUndo : seat peut-il annuler le dernier coup ? Entre deux joueurs,
+// seulement le sien (avant la réponse de l'adversaire). Sous sess.mu.
+func (sess *session) canUndo(seat int) bool {
+	if sess.ranked || !sess.g.CanUndo() {
+		return false
+	}
+	if !sess.twoPlayers() {
+		return true
+	}
+	h := sess.g.History()
+	return seat != 0 && h[len(h)-1].Player == seat
+}
+
+// canRedo : seat peut-il rejouer le coup annulé ? Entre deux joueurs,
+// seulement son auteur, c'est-à-dire le joueur au trait. Sous sess.mu.
+func (sess *session) canRedo(seat int) bool {
+	if sess.ranked || !sess.g.CanRedo() {
+		return false
+	}
+	return !sess.twoPlayers() || (seat != 0 && sess.g.CurrentPlayer == seat)
+}
+
+// rearmClock relance la pendule pour le joueur au trait après un
+// déplacement dans l'historique (appelé sous sess.mu).
+func (sess *session) rearmClock(now time.Time) {
+	sess.persist()
+	sess.stopTimer()
+	switch {
+	case sess.g.MoveCount == 0:
+		sess.resetClock()
+		sess.botOpens()
+	case sess.g.Winner != 0:
+		sess.clock.Stop(now)
+	default:
+		sess.clock.Stop(now)
+		sess.nextTurn(false)
+	}
+}
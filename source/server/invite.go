@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"power4/auth"
+)
+
+// defaultInviteTTL : durée de validité d'un lien d'invitation (INVITE_TTL)
+const defaultInviteTTL = 24 * time.Hour
+
+// Raisons de refus d'une invitation
+var (
+	errInviteUnknown = errors.New("invite not found")
+	errInviteExpired = errors.New("invite expired")
+	errUnverified    = errors.New("verify your email to play ranked games")
+)
+
+// newInviteToken : 128 bits aléatoires, encodés pour une URL
+func newInviteToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// inviteURL : lien à partager pour une partie privée ("" une fois la place
+// P2 prise).
+// Appelé sous sess.mu.
+func (sess *session) inviteURL() string {
+	if sess.invite == "" || sess.Players[1] != "" || sess.g.MoveCount > 0 {
+		return ""
+	}
+	return "/invite/" + sess.invite
+}
+
+// AcceptInvite assoit user à la place P2 de la partie invitée par token.
+// Le lien n'assoit qu'un joueur et seulement tant que la partie n'a pas
+// commencé ; ensuite il ouvre la partie en spectateur. Il expire après
+// inviteTTL. Un joueur déjà assis retrouve simplement sa partie. Une
+// partie classée n'assoit qu'un compte à l'email vérifié.
+func (reg *Registry) AcceptInvite(token, user string, now time.Time) (*session, error) {
+	reg.mu.Lock()
+	sess := reg.games[reg.invites[token]]
+	reg.mu.Unlock()
+	if sess == nil {
+		return nil, errInviteUnknown
+	}
+	verified := auth.EmailVerified(context.Background(), user) // hors du verrou (requête BDD)
+
+	sess.mu.Lock()
+	switch {
+	case sess.seatOf(user) != 0:
+		sess.mu.Unlock()
+		return sess, nil
+	case sess.invite != token:
+		sess.mu.Unlock()
+		return nil, errInviteUnknown
+	case !now.Before(sess.inviteExpires):
+		sess.mu.Unlock()
+		return nil, errInviteExpired
+	case sess.Players[1] != "" || sess.g.MoveCount > 0:
+		sess.watchers[user] = true // partie commencée : accès spectateur
+		sess.mu.Unlock()
+		return sess, nil
+	case sess.ranked && !verified:
+		sess.mu.Unlock()
+		return nil, errUnverified
+	}
+	sess.Players[1] = user
+	sess.seatPlayer2()
+	sess.startClock(time.Now())
+	sess.broadcastState()
+	sess.mu.Unlock()
+
+	reg.mu.Lock()
+	reg.byUser[user] = sess.ID
+	reg.mu.Unlock()
+	log.Printf("server: %s accepts invite to game %s", user, sess.ID)
+	return sess, nil
+}
+
+// handleInvite : GET /invite/{token} — rejoint (ou regarde) la partie privée.
+func (s *Server) handleInvite(w http.ResponseWriter, r *http.Request, user string) {
+	sess, err := s.games.AcceptInvite(r.PathValue("token"), user, time.Now())
+	switch err {
+	case nil:
+	case errInviteUnknown:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errUnverified:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
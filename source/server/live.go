@@ -0,0 +1,198 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"power4/game"
+)
+
+// event : message temps réel diffusé aux clients d'une partie (WebSocket,
+// SSE) — un des types *Event ci-dessous. Le protocole est décrit dans ws.go.
+type event any
+
+// moveEvent : coup n° MoveNo (1 = premier coup)
+type moveEvent struct {
+	Type   string `json:"type"` // "move"
+	Gen    int    `json:"gen"`  // génération de l'historique (voir session.gen)
+	MoveNo int    `json:"moveNo"`
+	Col    int    `json:"col"`
+	Row    int    `json:"row"`
+	Player int    `json:"player"`
+}
+
+// turnEvent : joueur au trait
+type turnEvent struct {
+	Type   string `json:"type"` // "turn"
+	Player int    `json:"player"`
+}
+
+// clockEvent : état de la pendule
+type clockEvent struct {
+	Type       string   `json:"type"`       // "clock"
+	TimeLeftMs int64    `json:"timeLeftMs"` // -1 = illimité
+	UseBank    bool     `json:"useBank"`
+	BankMs     [2]int64 `json:"bankMs"`
+}
+
+// winnerEvent : fin de partie (1 ou 2, -1 pour un match nul) et cases des
+// lignes gagnantes
+type winnerEvent struct {
+	Type   string          `json:"type"` // "winner"
+	Winner int             `json:"winner"`
+	Cells  []game.Position `json:"cells,omitempty"`
+}
+
+// spectatorsEvent : nombre de spectateurs connectés
+type spectatorsEvent struct {
+	Type  string `json:"type"` // "spectators"
+	Count int    `json:"count"`
+}
+
+// stateEvent : instantané complet
+type stateEvent struct {
+	Type  string    `json:"type"` // "state"
+	State stateData `json:"state"`
+}
+
+// hub : abonnés temps réel d'une partie. Un abonné trop lent (file pleine)
+// est déconnecté plutôt que de bloquer la partie.
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan event]struct{}
+}
+
+func newHub() *hub { return &hub{subs: make(map[chan event]struct{})} }
+
+// subscribe renvoie un canal d'événements ; il est fermé par unsubscribe
+// ou si l'abonné ne suit plus.
+func (h *hub) subscribe() chan event {
+	ch := make(chan event, 64)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// count : nombre de clients connectés.
+func (h *hub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+func (h *hub) publish(evs ...event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		for _, e := range evs {
+			select {
+			case ch <- e:
+			default:
+				delete(h.subs, ch)
+				close(ch)
+			}
+			if _, ok := h.subs[ch]; !ok {
+				break
+			}
+		}
+	}
+}
+
+// close déconnecte tous les abonnés (partie évincée).
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+func newMoveEvent(gen, moveNo int, m game.Move) moveEvent {
+	return moveEvent{Type: "move", Gen: gen, MoveNo: moveNo, Col: m.Col, Row: m.Row, Player: m.Player}
+}
+
+// clockState : événement "clock" (appelé sous sess.mu).
+func (sess *session) clockState(now time.Time) clockEvent {
+	ev := clockEvent{Type: "clock", TimeLeftMs: -1, UseBank: sess.clock.UseBank}
+	if left := sess.clock.Remaining(now); left >= 0 {
+		ev.TimeLeftMs = left.Milliseconds()
+	}
+	for p := game.P1; p <= game.P2; p++ {
+		ev.BankMs[p-1] = sess.clock.BankLeft(p, now).Milliseconds()
+	}
+	return ev
+}
+
+// turnEvents : fin de partie, ou joueur au trait + pendule (sous sess.mu).
+func (sess *session) turnEvents(now time.Time) []event {
+	if sess.g.Winner != 0 {
+		return []event{winnerEvent{Type: "winner", Winner: sess.g.Winner, Cells: sess.g.WinningCells()}}
+	}
+	return []event{turnEvent{Type: "turn", Player: sess.g.CurrentPlayer}, sess.clockState(now)}
+}
+
+// broadcastMove diffuse le dernier coup joué et ses conséquences (sous sess.mu).
+func (sess *session) broadcastMove() {
+	hist := sess.g.History()
+	if len(hist) == 0 {
+		return
+	}
+	evs := []event{newMoveEvent(sess.gen, len(hist), hist[len(hist)-1])}
+	sess.live.publish(append(evs, sess.turnEvents(time.Now())...)...)
+}
+
+// broadcastState diffuse un instantané complet, après un changement qui ne
+// se résume pas à un coup (reset, annulation, gravité…). Appelé sous sess.mu.
+// Quand l'historique a été réécrit, l'appelant incrémente d'abord sess.gen.
+func (sess *session) broadcastState() {
+	sess.live.publish(stateEvent{Type: "state", State: sess.state(time.Now())})
+}
+
+// parseSince lit un point de reprise "G.N" (génération G, N coups vus ;
+// voir session.gen). Vide → (-1, -1) ; un simple "N" sans génération ne
+// correspond à aucune génération et vaut un instantané complet.
+func parseSince(v string) (gen, since int, err error) {
+	if v == "" {
+		return -1, -1, nil
+	}
+	g, n, ok := strings.Cut(v, ".")
+	if !ok {
+		g, n = "-1", v
+	}
+	if gen, err = strconv.Atoi(g); err != nil {
+		return 0, 0, err
+	}
+	since, err = strconv.Atoi(n)
+	return gen, since, err
+}
+
+// resumeEvents : événements à envoyer à un client qui (re)vient en ayant vu
+// les since premiers coups de la génération gen. since < 0, autre
+// génération ou historique incohérent → instantané complet. Appelé sous
+// sess.mu.
+func (sess *session) resumeEvents(gen, since int) []event {
+	now := time.Now()
+	hist := sess.g.History()
+	if since < 0 || gen != sess.gen || since > len(hist) || len(hist) != sess.g.MoveCount {
+		return []event{stateEvent{Type: "state", State: sess.state(now)}}
+	}
+	evs := make([]event, 0, len(hist)-since+3)
+	for i := since; i < len(hist); i++ {
+		evs = append(evs, newMoveEvent(sess.gen, i+1, hist[i]))
+	}
+	evs = append(evs, sess.turnEvents(now)...)
+	return append(evs, spectatorsEvent{Type: "spectators", Count: sess.spectatorCount()})
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"power4/auth"
+)
+
+// lobbyGame : une partie publique en attente d'adversaire
+type lobbyGame struct {
+	URL        string
+	Owner      string
+	Rating     int
+	Rows, Cols int
+	ConnectN   int
+	Ranked     bool
+	Mine       bool   // créée par le joueur qui consulte le lobby
+	Waiting    string // depuis combien de temps ("3 min")
+}
+
+// liveGame : une partie publique en cours, à regarder
+type liveGame struct {
+	URL        string // ouverture en spectateur
+	Players    [2]string
+	MoveCount  int
+	Spectators int
+}
+
+type lobbyData struct {
+	User   string
+	Rating int
+	Games  []lobbyGame
+	Live   []liveGame
+	Queue  queueStatus
+	CSRF   string // jeton des formulaires et de la file (voir auth/csrf.go)
+}
+
+// handleLobby liste les parties publiques ouvertes (games.status = 'pending',
+// privacy = 'public', place P2 libre) encore présentes en mémoire, et les
+// parties publiques en cours que l'on peut regarder.
+func (s *Server) handleLobby(w http.ResponseWriter, r *http.Request, user string) {
+	ctx := r.Context()
+	data := lobbyData{
+		CSRF:   auth.CSRFToken(w, r),
+		User:   user,
+		Rating: ratingOf(ctx, user),
+		Queue:  s.queue.Status(user),
+	}
+
+	if store := auth.Games(); store != nil {
+		recs, err := store.ListOpenGames(ctx, 50)
+		if err != nil {
+			log.Printf("server: list open games: %v", err)
+		}
+		live := s.games.byRecord()
+		now := time.Now()
+		for _, rec := range recs {
+			sess := live[rec.ID]
+			if sess == nil {
+				continue // partie évincée ou serveur redémarré
+			}
+			sess.mu.Lock()
+			open := sess.online && sess.Players[1] == "" && sess.dbID == rec.ID
+			g := lobbyGame{
+				URL:      gameURL(sess, ""),
+				Owner:    sess.Players[0],
+				Rows:     sess.g.Rows,
+				Cols:     sess.g.Cols,
+				ConnectN: sess.g.ConnectN,
+				Ranked:   sess.ranked,
+				Mine:     sess.Players[0] == user,
+				Waiting:  fmt.Sprintf("%d min", int(now.Sub(rec.CreatedAt).Minutes())),
+			}
+			sess.mu.Unlock()
+			if !open {
+				continue
+			}
+			g.Rating = auth.RatingOf(ctx, rec.Player1ID)
+			data.Games = append(data.Games, g)
+		}
+	}
+
+	data.Live = s.games.livePublic(user)
+
+	if err := s.tpls.ExecuteTemplate(w, "lobby", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleLobbyCreate ouvre une partie en attente d'adversaire :
+// POST /lobby/create (size=small|medium|large, ranked=1, private=1). Une
+// partie privée n'apparaît pas dans le lobby, on la rejoint par son lien
+// d'invitation.
+func (s *Server) handleLobbyCreate(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/lobby", http.StatusSeeOther)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	s.queue.Leave(user)
+	opt := onlineOptions{
+		Size:    r.Form.Get("size"),
+		Private: r.Form.Get("private") == "1",
+		Ranked:  r.Form.Get("ranked") == "1",
+	}
+	if opt.Ranked && !auth.EmailVerified(r.Context(), user) {
+		http.Error(w, errUnverified.Error(), http.StatusForbidden)
+		return
+	}
+	sess := s.games.CreateOnline(user, opt)
+	log.Printf("server: %s opens game %s (private=%v)", user, sess.ID, opt.Private)
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
+
+// handleQueue : file de parties rapides. POST y entre, GET donne l'état
+// (à interroger régulièrement), DELETE en sort. Réponse : queueStatus.
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request, user string) {
+	var st queueStatus
+	switch r.Method {
+	case http.MethodPost:
+		// la file rapide ne produit que des parties classées
+		if !auth.EmailVerified(r.Context(), user) {
+			http.Error(w, errUnverified.Error(), http.StatusForbidden)
+			return
+		}
+		st = s.queue.Join(user)
+	case http.MethodGet:
+		st = s.queue.Status(user)
+	case http.MethodDelete:
+		s.queue.Leave(user)
+		st = queueStatus{Status: "idle"}
+	default:
+		http.Error(w, "invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
+
+// ratingOf : classement du compte username (DefaultRating s'il est inconnu).
+func ratingOf(ctx context.Context, username string) int {
+	return auth.RatingOf(ctx, auth.UserID(ctx, username))
+}
+
+// livePublic : parties en ligne publiques en cours (deux joueurs, pas de
+// vainqueur) dont user n'est pas joueur, les plus suivies d'abord.
+func (reg *Registry) livePublic(user string) []liveGame {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	var out []liveGame
+	for _, sess := range reg.games {
+		sess.mu.Lock()
+		if sess.online && sess.privacy == auth.PrivacyPublic && sess.Players[1] != "" &&
+			sess.g.Winner == 0 && sess.seatOf(user) == 0 {
+			out = append(out, liveGame{
+				URL:        gameURL(sess, "") + "?watch=1",
+				Players:    sess.Players,
+				MoveCount:  sess.g.MoveCount,
+				Spectators: sess.spectatorCount(),
+			})
+		}
+		sess.mu.Unlock()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Spectators > out[j].Spectators })
+	return out
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fenêtre de classement acceptée par un joueur en file : elle s'élargit avec
+// l'attente pour qu'un joueur isolé finisse par trouver un adversaire.
+const (
+	matchWindowBase  = 100              // écart de classement accepté d'emblée
+	matchWindowStep  = 50               // élargissement…
+	matchWindowEvery = 10 * time.Second // …à chaque intervalle d'attente
+	matchWindowMax   = 1000
+	matchIdle        = 30 * time.Second // sans nouvelle du client, on le retire de la file
+)
+
+// queueEntry : un joueur en attente d'adversaire
+type queueEntry struct {
+	user     string
+	rating   int
+	since    time.Time // entrée dans la file
+	lastPoll time.Time
+	gameID   string // partie trouvée ("" = en attente)
+}
+
+// window : écart de classement accepté après l'attente jusqu'à now.
+func (e *queueEntry) window(now time.Time) int {
+	w := matchWindowBase + matchWindowStep*int(now.Sub(e.since)/matchWindowEvery)
+	if w > matchWindowMax {
+		w = matchWindowMax
+	}
+	return w
+}
+
+// queueStatus : réponse JSON de /lobby/queue
+type queueStatus struct {
+	Status   string `json:"status"` // "idle" | "waiting" | "matched"
+	Rating   int    `json:"rating,omitempty"`
+	WaitedMs int64  `json:"waitedMs,omitempty"`
+	Window   int    `json:"window,omitempty"`
+	URL      string `json:"url,omitempty"` // partie trouvée
+}
+
+// matchmaker : file de parties rapides. Les joueurs sont appariés par
+// classement proche ; une partie en ligne classée est créée pour chaque paire.
+type matchmaker struct {
+	mu      sync.Mutex
+	entries map[string]*queueEntry
+	games   *Registry
+}
+
+func newMatchmaker(games *Registry) *matchmaker {
+	mm := &matchmaker{entries: make(map[string]*queueEntry), games: games}
+	go mm.loop()
+	return mm
+}
+
+// Join place user dans la file (sans effet s'il y est déjà).
+func (mm *matchmaker) Join(user string) queueStatus {
+	rating := ratingOf(context.Background(), user)
+
+	mm.mu.Lock()
+	now := time.Now()
+	if _, ok := mm.entries[user]; !ok {
+		mm.entries[user] = &queueEntry{user: user, rating: rating, since: now}
+	}
+	mm.mu.Unlock()
+
+	mm.pair(now)
+	return mm.Status(user)
+}
+
+// Status renvoie l'état de user dans la file ; une partie trouvée n'est
+// annoncée qu'une fois, le joueur quitte alors la file.
+func (mm *matchmaker) Status(user string) queueStatus {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	e, ok := mm.entries[user]
+	if !ok {
+		return queueStatus{Status: "idle"}
+	}
+	now := time.Now()
+	e.lastPoll = now
+	if e.gameID != "" {
+		delete(mm.entries, user)
+		return queueStatus{Status: "matched", URL: "/games/" + e.gameID}
+	}
+	return queueStatus{
+		Status:   "waiting",
+		Rating:   e.rating,
+		WaitedMs: now.Sub(e.since).Milliseconds(),
+		Window:   e.window(now),
+	}
+}
+
+// Leave retire user de la file.
+func (mm *matchmaker) Leave(user string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if e, ok := mm.entries[user]; ok && e.gameID == "" {
+		delete(mm.entries, user)
+	}
+}
+
+// pair apparie les joueurs en attente : du plus ancien au plus récent, chacun
+// prend l'adversaire le plus proche dont l'écart tient dans les deux fenêtres.
+func (mm *matchmaker) pair(now time.Time) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	waiting := make([]*queueEntry, 0, len(mm.entries))
+	for user, e := range mm.entries {
+		if e.lastPoll.IsZero() {
+			e.lastPoll = e.since
+		}
+		if now.Sub(e.lastPoll) > matchIdle {
+			delete(mm.entries, user) // onglet fermé
+			continue
+		}
+		if e.gameID == "" {
+			waiting = append(waiting, e)
+		}
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].since.Before(waiting[j].since) })
+
+	for i, a := range waiting {
+		if a.gameID != "" {
+			continue
+		}
+		var best *queueEntry
+		bestDiff := 0
+		for _, b := range waiting[i+1:] {
+			if b.gameID != "" {
+				continue
+			}
+			diff := a.rating - b.rating
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > a.window(now) || diff > b.window(now) {
+				continue
+			}
+			if best == nil || diff < bestDiff {
+				best, bestDiff = b, diff
+			}
+		}
+		if best == nil {
+			continue
+		}
+		// le joueur qui attend depuis le plus longtemps a les jetons du joueur 1
+		sess := mm.games.CreateOnline(a.user, onlineOptions{Opponent: best.user, Ranked: true})
+		a.gameID, best.gameID = sess.ID, sess.ID
+		log.Printf("server: quick match %s (%d) vs %s (%d) → %s", a.user, a.rating, best.user, best.rating, sess.ID)
+	}
+}
+
+func (mm *matchmaker) loop() {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for now := range t.C {
+		mm.pair(now)
+	}
+}
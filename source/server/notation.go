@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"power4/game"
+)
+
+// maxNotationBytes : taille maximale d'une partie importée
+const maxNotationBytes = 64 << 10
+
+// handleExport : GET /games/{id}/export — la partie en notation texte (voir
+// game/notation.go), pour la partager dans un chat ou un rapport de bug.
+// Comme pour le replay, {id} peut être l'id numérique d'une partie en base.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request, user string) {
+	id := r.PathValue("id")
+	data, moves, status := s.recorded(r.Context(), id, user)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	// rejouer la partie retrouve la gravité des coups lus en base
+	g, err := game.Replay(data.Rows, data.Cols, data.ConnectN, moves, nil)
+	if err != nil {
+		log.Printf("server: export game %s: %v", id, err)
+		http.Error(w, "corrupted game record", http.StatusInternalServerError)
+		return
+	}
+
+	tags := map[string]string{
+		"Date":   data.Date.Format("2006.01.02"),
+		"Result": game.ResultOf(data.Winner),
+	}
+	for i, p := range data.Players {
+		if p != "" {
+			tags[fmt.Sprintf("Player%d", i+1)] = p
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", "power4-"+id+".txt"))
+	fmt.Fprint(w, game.NotationOf(g, tags))
+}
+
+// handleImport : POST /games/import — crée une partie locale à partir d'une
+// notation (champ "notation") et y redirige. La position est reconstruite
+// coup par coup via Drop ; la partie importée n'est pas enregistrée en base.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request, user string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxNotationBytes)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	n, err := game.ParseNotation(r.Form.Get("notation"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tmpl, ok := boardTemplate(n.Rows, n.Cols)
+	if !ok {
+		http.Error(w, "unsupported board size", http.StatusBadRequest)
+		return
+	}
+	if n.ConnectN < 3 || (n.ConnectN > n.Rows && n.ConnectN > n.Cols) {
+		http.Error(w, "invalid connect", http.StatusBadRequest)
+		return
+	}
+	g, err := n.Game()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sess := s.games.Create(user)
+	sess.mu.Lock()
+	sess.store = nil // partie d'un autre : ni statistiques ni historique en base
+	sess.g = g
+	sess.boardTmpl = tmpl
+	sess.rearmClock(time.Now())
+	sess.mu.Unlock()
+	log.Printf("server: %s imports a %dx%d game (%d moves) as %s", user, n.Rows, n.Cols, len(n.Moves), sess.ID)
+
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	"power4/auth"
+	"power4/game"
+)
+
+// persist synchronise la partie avec le GameStore (appelé sous sess.mu) :
+// création de la ligne games au premier coup (dès la création en ligne), ajout des coups manquants,
+// suppression des coups annulés et statut final. Les parties contre
+// l'ordinateur ne sont pas enregistrées.
+func (sess *session) persist() {
+	if sess.store == nil || sess.bot != 0 {
+		return
+	}
+	ctx := context.Background()
+	hist := sess.g.History()
+
+	if sess.dbID == 0 {
+		if len(hist) == 0 && !sess.online {
+			return
+		}
+		sess.userIDs[0] = auth.UserID(ctx, sess.Players[0])
+		sess.userIDs[1] = auth.UserID(ctx, sess.Players[1])
+		if sess.userIDs[0] == 0 {
+			return // propriétaire inconnu (compte supprimé)
+		}
+		rec := &auth.GameRecord{
+			Player1ID: sess.userIDs[0],
+			Player2ID: sess.userIDs[1],
+			Rows:      sess.g.Rows,
+			Cols:      sess.g.Cols,
+			ConnectN:  sess.g.ConnectN,
+			Privacy:   sess.privacy,
+		}
+		if err := sess.store.CreateGame(ctx, rec); err != nil {
+			log.Printf("server: create game %s: %v", sess.ID, err)
+			return
+		}
+		sess.dbID, sess.saved, sess.dbStatus = rec.ID, 0, auth.GamePending
+	}
+
+	if len(hist) < sess.saved {
+		if err := sess.store.TruncateMoves(ctx, sess.dbID, len(hist)); err != nil {
+			log.Printf("server: truncate moves of game %d: %v", sess.dbID, err)
+			return
+		}
+		sess.saved = len(hist)
+	}
+	for i := sess.saved; i < len(hist); i++ {
+		m := hist[i]
+		color := "R"
+		if m.Player == game.P2 {
+			color = "Y"
+		}
+		mv := auth.MoveRecord{
+			MoveNo:   i + 1,
+			PlayerID: sess.seatUserID(m.Player),
+			Col:      m.Col,
+			Row:      m.Row,
+			Color:    color,
+		}
+		if err := sess.store.RecordMove(ctx, sess.dbID, mv); err != nil {
+			log.Printf("server: record move %d of game %d: %v", i+1, sess.dbID, err)
+			return
+		}
+		sess.saved = i + 1
+		sess.dbStatus = auth.GameActive
+	}
+
+	status, result := auth.GameActive, ""
+	switch sess.g.Winner {
+	case game.P1:
+		status, result = auth.GameFinished, auth.ResultP1
+	case game.P2:
+		status, result = auth.GameFinished, auth.ResultP2
+	case -1:
+		status, result = auth.GameFinished, auth.ResultDraw
+	}
+	if status != sess.dbStatus && (status == auth.GameFinished || sess.dbStatus == auth.GameFinished) {
+		if err := sess.store.UpdateStatus(ctx, sess.dbID, status, result); err != nil {
+			log.Printf("server: update status of game %d: %v", sess.dbID, err)
+			return
+		}
+		sess.dbStatus = status
+		if status == auth.GameFinished && sess.ranked {
+			sess.rate(ctx)
+		}
+	}
+}
+
+// rate met à jour le classement des deux comptes d'une partie classée
+// terminée (appelé sous sess.mu, une seule fois par partie).
+func (sess *session) rate(ctx context.Context) {
+	p1, p2 := sess.userIDs[0], sess.userIDs[1]
+	if p1 == 0 || p2 == 0 || p1 == p2 {
+		return // hot-seat : pas de classement
+	}
+	score := 0.5
+	switch sess.g.Winner {
+	case game.P1:
+		score = 1
+	case game.P2:
+		score = 0
+	}
+	if err := auth.RecordResult(ctx, p1, p2, score); err != nil {
+		log.Printf("server: rating update for game %d: %v", sess.dbID, err)
+	}
+}
+
+// seatUserID : compte qui joue les jetons de player. En hot-seat (place P2
+// libre), les coups du joueur 2 sont rattachés au joueur 1.
+func (sess *session) seatUserID(player int) int {
+	if player == game.P2 && sess.userIDs[1] != 0 {
+		return sess.userIDs[1]
+	}
+	return sess.userIDs[0]
+}
+
+// abandon clôt la ligne games d'une partie non terminée avant un reset ou
+// une éviction (appelé sous sess.mu).
+func (sess *session) abandon() {
+	if sess.store != nil && sess.dbID != 0 && sess.dbStatus != auth.GameFinished {
+		if err := sess.store.UpdateStatus(context.Background(), sess.dbID, auth.GameAbandoned, ""); err != nil {
+			log.Printf("server: abandon game %d: %v", sess.dbID, err)
+		}
+	}
+	sess.dbID, sess.saved, sess.dbStatus = 0, 0, ""
+	sess.userIDs = [2]int{}
+}
+
+// seatPlayer2 enregistre en base le compte qui vient de prendre la place P2
+// (appelé sous sess.mu).
+func (sess *session) seatPlayer2() {
+	if sess.store == nil || sess.dbID == 0 {
+		return
+	}
+	ctx := context.Background()
+	sess.userIDs[1] = auth.UserID(ctx, sess.Players[1])
+	if err := sess.store.SetPlayer2(ctx, sess.dbID, sess.userIDs[1]); err != nil {
+		log.Printf("server: seat player 2 in game %d: %v", sess.dbID, err)
+	}
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"power4/auth"
+	"power4/game"
+	"power4/game/ai"
+)
+
+// session : une partie indépendante + son affichage + ses joueurs
+type session struct {
+	mu        sync.Mutex
+	ID        string
+	g         *game.Game
+	boardTmpl string    // "board_small" | "board_medium" | "board_large"
+	Players   [2]string // pseudos des joueurs (P1, P2), "" si place libre
+	lastSeen  time.Time
+	ranked    bool // partie classée : pas d'annulation
+
+	// partie en ligne (lobby, matchmaking) : chaque joueur ne joue que ses
+	// jetons et la partie est enregistrée dès sa création
+	online  bool
+	privacy string // auth.PrivacyPublic | auth.PrivacyPrivate
+
+	// partie privée : jeton du lien d'invitation et date d'expiration
+	// (voir invite.go)
+	invite        string
+	inviteExpires time.Time
+
+	// spectateurs (voir spectate.go) : comptes admis à regarder une partie
+	// privée, et connexions temps réel ouvertes par pseudo
+	watchers   map[string]bool
+	spectators map[string]int
+
+	// pendule côté serveur (voir clock.go)
+	clock    *game.Clock
+	clockCfg clockConfig
+	timer    *time.Timer
+	turnSeq  int // invalide les expirations programmées pour un coup passé
+
+	// partie contre l'ordinateur : siège du bot (0 = pas de bot) et niveau
+	bot      int
+	botLevel ai.Level
+
+	// enregistrement en base (voir persist.go)
+	store    auth.GameStore
+	dbID     int64  // id de la ligne games (0 = pas encore créée)
+	saved    int    // nombre de coups déjà enregistrés
+	dbStatus string // dernier statut écrit
+	userIDs  [2]int // comptes de P1 / P2 (0 = aucun)
+
+	// clients temps réel (WebSocket, voir live.go). gen change à chaque
+	// réécriture de l'historique (annulation, reset, gravité…) : un client
+	// qui revient avec une autre génération reçoit un instantané complet.
+	live *hub
+	gen  int
+}
+
+// touch met à jour la date de dernière activité (appelé sous s.mu)
+func (s *session) touch() { s.lastSeen = time.Now() }
+
+// Registry : ensemble des parties en cours, indexées par identifiant
+type Registry struct {
+	mu     sync.Mutex
+	games  map[string]*session
+	byUser map[string]string // pseudo → partie courante
+	ttl    time.Duration
+
+	invites   map[string]string // jeton d'invitation → partie
+	inviteTTL time.Duration     // durée de validité d'une invitation
+	store     auth.GameStore    // nil : pas d'enregistrement
+}
+
+// NewRegistry crée un registre dont les parties inactives depuis plus de ttl
+// sont supprimées. ttl <= 0 désactive l'éviction ; store peut être nil.
+func NewRegistry(ttl time.Duration, store auth.GameStore) *Registry {
+	reg := &Registry{
+		games:     make(map[string]*session),
+		byUser:    make(map[string]string),
+		ttl:       ttl,
+		store:     store,
+		invites:   make(map[string]string),
+		inviteTTL: defaultInviteTTL,
+	}
+	if ttl > 0 {
+		go reg.evictLoop()
+	}
+	return reg
+}
+
+// Create démarre une nouvelle partie medium (6x9) dont owner est le joueur 1.
+func (reg *Registry) Create(owner string) *session {
+	sess := &session{
+		ID:         newGameID(),
+		g:          game.New(6, 9, game.DefaultConnectN), // medium par défaut
+		boardTmpl:  "board_medium",
+		lastSeen:   time.Now(),
+		clockCfg:   defaultClock,
+		privacy:    auth.PrivacyPublic,
+		store:      reg.store,
+		live:       newHub(),
+		watchers:   make(map[string]bool),
+		spectators: make(map[string]int),
+	}
+	sess.Players[0] = owner
+	sess.resetClock()
+
+	reg.mu.Lock()
+	reg.games[sess.ID] = sess
+	if owner != "" {
+		reg.byUser[owner] = sess.ID
+	}
+	reg.mu.Unlock()
+	return sess
+}
+
+// Get renvoie la partie id, ou nil si elle n'existe pas (ou a été évincée).
+func (reg *Registry) Get(id string) *session {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.games[id]
+}
+
+// Current renvoie la partie courante d'un joueur, en la créant au besoin.
+func (reg *Registry) Current(user string) *session {
+	reg.mu.Lock()
+	id, ok := reg.byUser[user]
+	sess := reg.games[id]
+	reg.mu.Unlock()
+	if ok && sess != nil {
+		return sess
+	}
+	return reg.Create(user)
+}
+
+// Join assoit user dans la partie (place P2 si libre, sauf partie privée :
+// voir AcceptInvite, ou classée sans email vérifié) et en fait sa partie
+// courante. Renvoie le numéro de joueur (1 ou 2), ou 0 s'il n'y a pas de
+// place.
+func (reg *Registry) Join(sess *session, user string) int {
+	// vérification de l'email faite hors du verrou, seulement si la place
+	// P2 est à prendre
+	sess.mu.Lock()
+	free := sess.Players[1] == "" && sess.seatOf(user) == 0
+	sess.mu.Unlock()
+	verified := free && auth.EmailVerified(context.Background(), user)
+
+	sess.mu.Lock()
+	seat := 0
+	switch {
+	case sess.Players[0] == user:
+		seat = game.P1
+	case sess.Players[1] == user:
+		seat = game.P2
+	case sess.Players[1] == "" && sess.privacy != auth.PrivacyPrivate && (!sess.ranked || verified):
+		sess.Players[1] = user
+		seat = game.P2
+		sess.seatPlayer2()
+		sess.startClock(time.Now())
+		sess.broadcastState()
+	}
+	sess.mu.Unlock()
+
+	if seat != 0 && user != "" {
+		reg.mu.Lock()
+		reg.byUser[user] = sess.ID
+		reg.mu.Unlock()
+	}
+	return seat
+}
+
+// onlineOptions : paramètres d'une partie en ligne
+type onlineOptions struct {
+	Opponent string // "" = place P2 libre
+	Size     string // small | medium | large (voir boardSize)
+	Private  bool   // accessible uniquement par invitation
+	Ranked   bool
+}
+
+// CreateOnline démarre une partie en ligne dont owner est le joueur 1.
+func (reg *Registry) CreateOnline(owner string, opt onlineOptions) *session {
+	sess := reg.Create(owner)
+
+	sess.mu.Lock()
+	if rows, cols, tmpl := boardSize(opt.Size); tmpl != sess.boardTmpl {
+		sess.g.Reset(rows, cols, game.DefaultConnectN)
+		sess.boardTmpl = tmpl
+	}
+	sess.online = true
+	sess.ranked = opt.Ranked
+	sess.Players[1] = opt.Opponent
+	sess.resetClock() // en ligne : la pendule attend le joueur 2
+	if opt.Private {
+		sess.privacy = auth.PrivacyPrivate
+		sess.invite = newInviteToken()
+		sess.inviteExpires = time.Now().Add(reg.inviteTTL)
+	}
+	invite := sess.invite
+	sess.persist() // ligne games 'pending', visible dans le lobby si publique et P2 libre
+	sess.mu.Unlock()
+
+	reg.mu.Lock()
+	if opt.Opponent != "" {
+		reg.byUser[opt.Opponent] = sess.ID
+	}
+	if invite != "" {
+		reg.invites[invite] = sess.ID
+	}
+	reg.mu.Unlock()
+	return sess
+}
+
+// byRecord renvoie les parties en mémoire, indexées par leur ligne games.
+func (reg *Registry) byRecord() map[int64]*session {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	out := make(map[int64]*session)
+	for _, sess := range reg.games {
+		sess.mu.Lock()
+		if sess.dbID != 0 {
+			out[sess.dbID] = sess
+		}
+		sess.mu.Unlock()
+	}
+	return out
+}
+
+// seatOf renvoie le numéro de joueur de user (0 s'il n'est pas assis).
+// Appelé sous sess.mu.
+func (sess *session) seatOf(user string) int {
+	switch {
+	case user == "":
+		return 0
+	case sess.Players[0] == user:
+		return game.P1
+	case sess.Players[1] == user:
+		return game.P2
+	}
+	return 0
+}
+
+// awaitsOpponent : partie en ligne ou classée dont la place P2 est libre ;
+// personne n'y joue avant l'arrivée d'un adversaire (sous sess.mu).
+func (sess *session) awaitsOpponent() bool {
+	return (sess.online || sess.ranked) && sess.Players[1] == ""
+}
+
+// mayPlay indique si user peut jouer le coup en cours (sous sess.mu). Tant
+// que la place P2 est libre, le propriétaire joue seul les deux couleurs
+// (hot-seat), sauf en ligne ou en classé où il attend un adversaire.
+func (sess *session) mayPlay(user string) bool {
+	if sess.Players[1] == "" {
+		return !sess.awaitsOpponent() && user == sess.Players[0]
+	}
+	return sess.seatOf(user) == sess.g.CurrentPlayer
+}
+
+// Evict supprime les parties inactives depuis plus que le TTL.
+func (reg *Registry) Evict(now time.Time) int {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	n := 0
+	for id, sess := range reg.games {
+		sess.mu.Lock()
+		idle := now.Sub(sess.lastSeen)
+		if idle <= reg.ttl {
+			sess.mu.Unlock()
+			continue
+		}
+		sess.stopTimer()
+		sess.abandon()
+		sess.mu.Unlock()
+		sess.live.close()
+		delete(reg.games, id)
+		n++
+	}
+	for user, id := range reg.byUser {
+		if _, ok := reg.games[id]; !ok {
+			delete(reg.byUser, user)
+		}
+	}
+	for token, id := range reg.invites {
+		if _, ok := reg.games[id]; !ok {
+			delete(reg.invites, token)
+		}
+	}
+	return n
+}
+
+func (reg *Registry) evictLoop() {
+	every := reg.ttl / 2
+	if every < time.Minute {
+		every = time.Minute
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for now := range t.C {
+		reg.Evict(now)
+	}
+}
+
+// newGameID : identifiant aléatoire (16 caractères hexa) non devinable
+func newGameID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"testing"
+
+	"power4/game"
+)
+
+func TestJoinSeats(t *testing.T) {
+	reg := NewRegistry(0, nil)
+
+	sess := reg.CreateOnline("alice", onlineOptions{Size: "small"})
+	if seat := reg.Join(sess, "alice"); seat != game.P1 {
+		t.Errorf("owner joined as %d, want P1", seat)
+	}
+	if seat := reg.Join(sess, "bob"); seat != game.P2 {
+		t.Fatalf("bob joined as %d, want P2", seat)
+	}
+	if seat := reg.Join(sess, "bob"); seat != game.P2 {
+		t.Errorf("bob rejoined as %d, want P2", seat)
+	}
+	if seat := reg.Join(sess, "carol"); seat != 0 {
+		t.Errorf("carol seated as %d in a full game", seat)
+	}
+	if cur := reg.Current("bob"); cur != sess {
+		t.Error("joined game is not bob's current game")
+	}
+
+	private := reg.CreateOnline("alice", onlineOptions{Size: "small", Private: true})
+	if seat := reg.Join(private, "bob"); seat != 0 {
+		t.Errorf("bob seated as %d in a private game without the invite", seat)
+	}
+
+	// sans compte vérifié (pas de repository ici), pas de place en classée
+	ranked := reg.CreateOnline("alice", onlineOptions{Size: "small", Ranked: true})
+	if seat := reg.Join(ranked, "bob"); seat != 0 {
+		t.Errorf("unverified bob seated as %d in a ranked game", seat)
+	}
+}
+
+func TestRankedAwaitsOpponent(t *testing.T) {
+	reg := NewRegistry(0, nil)
+	sess := reg.Create("alice")
+	sess.ranked = true
+
+	// partie classée à une place libre : personne ne joue, pas même P2 par
+	// le propriétaire
+	if !sess.awaitsOpponent() || sess.mayPlay("alice") {
+		t.Error("owner may play a ranked game with an empty seat")
+	}
+	sess.Players[1] = "bob"
+	if sess.awaitsOpponent() || !sess.mayPlay("alice") || sess.mayPlay("bob") {
+		t.Error("wrong turn in a full ranked game")
+	}
+
+	// partie locale : le propriétaire joue les deux couleurs
+	local := reg.Create("carol")
+	if local.awaitsOpponent() || !local.mayPlay("carol") {
+		t.Error("hot-seat owner may not play")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		in         string
+		gen, since int
+		ok         bool
+	}{
+		{"", -1, -1, true},
+		{"2.5", 2, 5, true},
+		{"5", -1, 5, true},
+		{"x.5", 0, 0, false},
+		{"2.x", 0, 0, false},
+	}
+	for _, tt := range tests {
+		gen, since, err := parseSince(tt.in)
+		if (err == nil) != tt.ok || (tt.ok && (gen != tt.gen || since != tt.since)) {
+			t.Errorf("parseSince(%q) = %d, %d, %v", tt.in, gen, since, err)
+		}
+	}
+}
+
+func TestResumeEventsGeneration(t *testing.T) {
+	reg := NewRegistry(0, nil)
+	sess := reg.Create("alice")
+	sess.g.Drop(0)
+	sess.g.Drop(1)
+
+	// même génération : seulement le coup manquant
+	evs := sess.resumeEvents(sess.gen, 1)
+	if mv, ok := evs[0].(moveEvent); !ok || mv.MoveNo != 2 {
+		t.Errorf("same generation: first event %#v, want move 2", evs[0])
+	}
+
+	// autre génération (partie recommencée, gravité…) : instantané complet
+	for _, gen := range []int{sess.gen - 1, sess.gen + 1, -1} {
+		evs = sess.resumeEvents(gen, 1)
+		if _, ok := evs[0].(stateEvent); !ok || len(evs) != 1 {
+			t.Errorf("generation %d: events %#v, want one state", gen, evs)
+		}
+	}
+}
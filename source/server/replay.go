@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"power4/auth"
+	"power4/game"
+)
+
+// replayFrame : position après le coup n° i (la frame 0 est le plateau vide)
+type replayFrame struct {
+	Col    int     `json:"col"`
+	Row    int     `json:"row"`
+	Player int     `json:"player"`
+	Board  [][]int `json:"board"`
+}
+
+type replayData struct {
+	ID         string // {id} de l'URL
+	GameURL    string // "" si la partie n'est plus en mémoire
+	Players    [2]string
+	Rows, Cols int
+	ConnectN   int
+	Winner     int             // 1, 2, -1 (nul) ou 0 (partie en cours ou abandonnée)
+	Cells      []game.Position // cases des lignes gagnantes
+	Gradable   bool            // coups notés par le solveur (voir grade.go)
+	Date       time.Time
+	Frames     []replayFrame
+}
+
+// handleReplay : GET /games/{id}/replay — revoit une partie coup par coup.
+// {id} est l'identifiant d'une partie en mémoire, ou l'id numérique de la
+// partie enregistrée en base une fois la session évincée. Les positions
+// sont reconstruites en rejouant les coups via game.Replay.
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request, user string) {
+	id := r.PathValue("id")
+	data, moves, status := s.recorded(r.Context(), id, user)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	data.Frames = make([]replayFrame, 0, len(moves)+1)
+	data.Frames = append(data.Frames, replayFrame{Board: game.New(data.Rows, data.Cols, data.ConnectN).Board})
+	final, err := game.Replay(data.Rows, data.Cols, data.ConnectN, moves, func(g *game.Game) {
+		hist := g.History()
+		m := hist[len(hist)-1]
+		data.Frames = append(data.Frames, replayFrame{Col: m.Col, Row: m.Row, Player: m.Player, Board: copyBoard(g.Board)})
+	})
+	if err != nil {
+		log.Printf("server: replay game %s: %v", id, err)
+		http.Error(w, "corrupted game record", http.StatusInternalServerError)
+		return
+	}
+	if data.Winner == 0 {
+		data.Winner = final.Winner
+	}
+	data.Cells = final.WinningCells()
+	data.ID = id
+	data.Gradable = gradable(data.Rows, data.Cols, data.ConnectN)
+
+	if err := s.tpls.ExecuteTemplate(w, "replay", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// recorded renvoie la description et les coups de la partie {id} : en
+// mémoire si elle y est encore, sinon lue en base (voir loadReplay).
+func (s *Server) recorded(ctx context.Context, id, user string) (replayData, []game.Move, int) {
+	sess := s.games.Get(id)
+	if sess == nil {
+		return loadReplay(ctx, id, user)
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !sess.canView(user) {
+		return replayData{}, nil, http.StatusForbidden
+	}
+	data := replayData{
+		GameURL:  gameURL(sess, ""),
+		Players:  sess.Players,
+		Rows:     sess.g.Rows,
+		Cols:     sess.g.Cols,
+		ConnectN: sess.g.ConnectN,
+		Winner:   sess.g.Winner,
+		Date:     time.Now(),
+	}
+	if sess.bot != 0 {
+		data.Players[sess.bot-1] = "Ordinateur"
+	}
+	return data, sess.g.History(), http.StatusOK
+}
+
+// loadReplay lit une partie enregistrée (id numérique) et ses coups.
+// Une partie privée n'est visible que de ses joueurs.
+func loadReplay(ctx context.Context, id, user string) (replayData, []game.Move, int) {
+	store := auth.Games()
+	dbID, err := strconv.ParseInt(id, 10, 64)
+	if store == nil || err != nil {
+		return replayData{}, nil, http.StatusNotFound
+	}
+	rec, err := store.GetGame(ctx, dbID)
+	if err != nil || rec == nil {
+		return replayData{}, nil, http.StatusNotFound
+	}
+	data := replayData{
+		Players:  [2]string{auth.Username(ctx, rec.Player1ID), auth.Username(ctx, rec.Player2ID)},
+		Rows:     rec.Rows,
+		Cols:     rec.Cols,
+		ConnectN: rec.ConnectN,
+		Date:     rec.CreatedAt,
+	}
+	if rec.Privacy == auth.PrivacyPrivate && user != data.Players[0] && user != data.Players[1] {
+		return replayData{}, nil, http.StatusForbidden
+	}
+	switch rec.Result {
+	case auth.ResultP1:
+		data.Winner = game.P1
+	case auth.ResultP2:
+		data.Winner = game.P2
+	}
+
+	recs, err := store.ListMoves(ctx, dbID)
+	if err != nil {
+		log.Printf("server: list moves of game %d: %v", dbID, err)
+		return replayData{}, nil, http.StatusInternalServerError
+	}
+	// la base ne garde pas la gravité : game.Replay la déduit de la ligne
+	moves := make([]game.Move, len(recs))
+	for i, m := range recs {
+		p := game.P1
+		if m.Color == "Y" {
+			p = game.P2
+		}
+		moves[i] = game.Move{Col: m.Col, Row: m.Row, Player: p}
+	}
+	return data, moves, http.StatusOK
+}
+
+func copyBoard(b [][]int) [][]int {
+	out := make([][]int, len(b))
+	for r := range b {
+		out[r] = append([]int(nil), b[r]...)
+	}
+	return out
+}
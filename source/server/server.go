@@ -1,36 +1,91 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"html/template"
 	"log"
 	"math/rand"
 	"net/http"
+	"os"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
+	"power4/auth"
 	"power4/game"
+	"power4/game/ai"
 )
 
 type viewData struct {
+	GameID          string
 	Board           [][]int
 	Rows, Cols      int
+	ConnectN        int
 	CurrentPlayer   int
 	Winner          int
+	WinningCells    []game.Position // cases des lignes gagnantes
+	MoveCount       int
+	Gen             int // génération de l'historique, point de reprise du temps réel
+	Players         [2]string
 	BoardTemplate   string
-	Debug           bool // ← pour le mode debug d'alignement
-	InvertedGravity bool // ← pour le mode gravité inversée
+	Ranked          bool   // partie classée
+	Waiting         bool   // partie en ligne sans adversaire
+	Spectator       bool   // le visiteur regarde sans jouer
+	CanJoin         bool   // place P2 libre : le visiteur peut s'asseoir (POST join)
+	Spectators      int    // spectateurs connectés
+	InviteURL       string // lien d'invitation d'une partie privée en attente
+	CanUndo         bool   // annulation possible (partie non classée, coups joués)
+	CanRedo         bool
+	InProgress      bool   // partie en ligne en cours : ni reset, ni nouvelle partie, ni gravité
+	CanAnalyze      bool   // conseils de colonnes disponibles (voir analyze.go)
+	TimeLeft        int    // secondes restantes pour le coup (-1 = illimité)
+	UseBank         bool   // réserve Fischer activée
+	Bank            [2]int // réserve restante (secondes) de P1 / P2
+	Debug           bool   // ← pour le mode debug d'alignement
+	InvertedGravity bool   // ← pour le mode gravité inversée
+	CSRF            string // jeton des formulaires et des fetch (voir auth/csrf.go)
 }
 
-// Server : état partagé (jeu) + templates + config d'affichage
+// stateData : état JSON d'une partie (/games/{id}/state)
+type stateData struct {
+	ID            string          `json:"id"`
+	Board         [][]int         `json:"board"`
+	Rows          int             `json:"rows"`
+	Cols          int             `json:"cols"`
+	ConnectN      int             `json:"connectN"`
+	CurrentPlayer int             `json:"currentPlayer"`
+	Winner        int             `json:"winner"`
+	WinningCells  []game.Position `json:"winningCells,omitempty"`
+	MoveCount     int             `json:"moveCount"`
+	Gen           int             `json:"gen"` // génération de l'historique (voir session.gen)
+	Players       [2]string       `json:"players"`
+	Spectators    int             `json:"spectators"`
+	TimeLeftMs    int64           `json:"timeLeftMs"` // -1 = illimité
+	UseBank       bool            `json:"useBank"`
+	BankMs        [2]int64        `json:"bankMs"`
+}
+
+// Server : registre des parties + templates
 type Server struct {
-	mu        sync.Mutex
-	g         *game.Game
-	tpls      *template.Template
-	boardTmpl string // "board_small" | "board_medium" | "board_large"
+	games *Registry
+	queue *matchmaker
+	tpls  *template.Template
+
+	analyzeRanked bool          // /analyze autorisé pendant les parties classées
+	grades        *gradeCache   // notations du solveur (voir grade.go)
+	analyses      *analyzeCache // analyses par position (voir analyze.go)
 }
 
+// gameHandler : handler qui agit sur une partie déjà résolue
+type gameHandler func(w http.ResponseWriter, r *http.Request, sess *session)
+
+// NewDefault crée le serveur ; GAME_TTL (ex. "30m") règle l'éviction des
+// parties inactives, INVITE_TTL (ex. "2h") la validité des liens d'invitation,
+// ANALYSIS_RANKED=1 autorise l'analyse pendant les parties classées et
+// OPENING_BOOKS (par défaut "books") désigne le dossier des bibliothèques
+// d'ouvertures.
 func NewDefault() *Server {
 	rand.Seed(time.Now().UnixNano())
 
@@ -42,6 +97,11 @@ func NewDefault() *Server {
 			}
 			return out
 		},
+		// json : valeur encodée en JSON, pour les attributs data-*
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
 	}
 
 	tpls := template.Must(template.New("").Funcs(fm).ParseFiles(
@@ -51,14 +111,45 @@ func NewDefault() *Server {
 		"templates/board_large.gohtml",
 		"templates/token_p1.gohtml",
 		"templates/token_p2.gohtml",
+		"templates/lobby.gohtml",
+		"templates/replay.gohtml",
 	))
 
-	g := game.New(6, 9) // medium par défaut
+	ttl := 30 * time.Minute
+	if v := os.Getenv("GAME_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			ttl = d
+		} else {
+			log.Printf("server: invalid GAME_TTL %q: %v", v, err)
+		}
+	}
+
+	// bibliothèques d'ouvertures du bot (générées par source/bookgen)
+	bookDir := "books"
+	if v := os.Getenv("OPENING_BOOKS"); v != "" {
+		bookDir = v
+	}
+	if n, err := ai.LoadBooks(bookDir); err != nil {
+		log.Printf("server: load opening books: %v", err)
+	} else {
+		log.Printf("server: %d opening book(s) loaded from %s", n, bookDir)
+	}
 
+	games := NewRegistry(ttl, auth.Games())
+	if v := os.Getenv("INVITE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			games.inviteTTL = d
+		} else {
+			log.Printf("server: invalid INVITE_TTL %q", v)
+		}
+	}
 	return &Server{
-		g:         g,
-		tpls:      tpls,
-		boardTmpl: "board_medium",
+		games:         games,
+		queue:         newMatchmaker(games),
+		tpls:          tpls,
+		analyzeRanked: os.Getenv("ANALYSIS_RANKED") == "1",
+		grades:        newGradeCache(),
+		analyses:      newAnalyzeCache(),
 	}
 }
 
@@ -67,28 +158,71 @@ func (s *Server) Listen(addr string) error {
 	// Serve static files (images, css, js) from the "static" directory
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
+	// Routes par partie : /games/{id} et ses actions
+	mux.HandleFunc("/games/{id}", safe(s.withGame(s.handleIndex)))
+	mux.HandleFunc("/games/{id}/join", safe(s.withGame(s.handleJoin)))
+	mux.HandleFunc("/games/{id}/play", safe(s.withGame(s.playersOnly(s.handlePlay))))
+	mux.HandleFunc("/games/{id}/random_move", safe(s.withGame(s.playersOnly(s.handleRandomMove))))
+	mux.HandleFunc("/games/{id}/reset", safe(s.withGame(s.playersOnly(s.handleReset))))
+	mux.HandleFunc("/games/{id}/new", safe(s.withGame(s.playersOnly(s.handleNew))))
+	mux.HandleFunc("/games/{id}/gravity", safe(s.withGame(s.playersOnly(s.handleGravity))))
+	mux.HandleFunc("/games/{id}/state", safe(s.withGame(s.handleState)))
+	mux.HandleFunc("/games/{id}/analyze", safe(s.withGame(s.handleAnalyze)))
+	mux.HandleFunc("/games/{id}/undo", safe(s.withGame(s.playersOnly(s.handleUndo))))
+	mux.HandleFunc("/games/{id}/redo", safe(s.withGame(s.playersOnly(s.handleRedo))))
+	mux.HandleFunc("/games/{id}/ws", safe(s.withGame(s.handleWS)))
+	mux.HandleFunc("/games/{id}/events", safe(s.withGame(s.handleEvents)))
+	// revue coup par coup, y compris des parties enregistrées (id numérique)
+	mux.HandleFunc("/games/{id}/replay", safe(s.withUser(s.handleReplay)))
+	mux.HandleFunc("/games/{id}/grade", safe(s.withUser(s.handleGrade)))
+	// notation texte : export d'une partie, import d'une position
+	mux.HandleFunc("/games/{id}/export", safe(s.withUser(s.handleExport)))
+	mux.HandleFunc("/games/import", safe(s.withUser(s.handleImport)))
+
+	// Lobby : parties publiques ouvertes et file de parties rapides
+	mux.HandleFunc("/lobby", safe(s.withUser(s.handleLobby)))
+	mux.HandleFunc("/lobby/create", safe(s.withUser(s.handleLobbyCreate)))
+	mux.HandleFunc("/lobby/queue", safe(s.withUser(s.handleQueue)))
+	mux.HandleFunc("/invite/{token}", safe(s.withUser(s.handleInvite)))
+
 	// Route game-specific paths to the server handlers; everything else falls
 	// back to the DefaultServeMux so that packages registering on the global
-	// mux (like auth.RegisterRoutes) continue to work.
+	// mux (like auth.RegisterRoutes) continue to work. Les anciennes routes
+	// agissent sur la partie courante du joueur connecté.
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/":
-			safe(s.handleIndex)(w, r)
+			safe(s.withGame(s.handleIndex))(w, r)
 			return
 		case "/play":
-			safe(s.handlePlay)(w, r)
+			safe(s.withGame(s.playersOnly(s.handlePlay)))(w, r)
 			return
 		case "/random_move":
-			safe(s.handleRandomMove)(w, r)
+			safe(s.withGame(s.playersOnly(s.handleRandomMove)))(w, r)
 			return
 		case "/reset":
-			safe(s.handleReset)(w, r)
+			safe(s.withGame(s.playersOnly(s.handleReset)))(w, r)
 			return
 		case "/new":
-			safe(s.handleNew)(w, r)
+			safe(s.withGame(s.playersOnly(s.handleNew)))(w, r)
 			return
 		case "/gravity":
-			safe(s.handleGravity)(w, r)
+			safe(s.withGame(s.playersOnly(s.handleGravity)))(w, r)
+			return
+		case "/state":
+			safe(s.withGame(s.handleState))(w, r)
+			return
+		case "/analyze":
+			safe(s.withGame(s.handleAnalyze))(w, r)
+			return
+		case "/undo":
+			safe(s.withGame(s.playersOnly(s.handleUndo)))(w, r)
+			return
+		case "/redo":
+			safe(s.withGame(s.playersOnly(s.handleRedo)))(w, r)
+			return
+		case "/vsbot":
+			safe(s.withUser(s.handleVsBot))(w, r)
 			return
 		default:
 			// Delegate to handlers registered on the default mux (auth, etc.)
@@ -97,8 +231,9 @@ func (s *Server) Listen(addr string) error {
 		}
 	})
 
+	// toute requête modifiante (POST, DELETE…) doit porter le jeton CSRF
 	log.Printf("Server listening on %s", addr)
-	return http.ListenAndServe(addr, mux)
+	return http.ListenAndServe(addr, auth.RequireCSRF(mux))
 }
 
 func safe(h http.HandlerFunc) http.HandlerFunc {
@@ -113,18 +248,108 @@ func safe(h http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	s.mu.Lock()
+// withUser exige un joueur connecté (sinon redirection vers /login).
+func (s *Server) withUser(h func(w http.ResponseWriter, r *http.Request, user string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := auth.CurrentUser(r)
+		if user == "" {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		h(w, r, user)
+	}
+}
+
+// withGame résout la partie visée : {id} dans l'URL, sinon la partie courante
+// du joueur connecté. Un visiteur qui n'est pas assis la regarde en
+// spectateur si elle est publique ou s'il y a été invité ; il ne prend la
+// place P2 que par POST /games/{id}/join (voir handleJoin).
+func (s *Server) withGame(h gameHandler) http.HandlerFunc {
+	return s.withUser(func(w http.ResponseWriter, r *http.Request, user string) {
+		var sess *session
+		if id := r.PathValue("id"); id != "" {
+			sess = s.games.Get(id)
+			if sess == nil {
+				http.Error(w, "game not found", http.StatusNotFound)
+				return
+			}
+		} else {
+			sess = s.games.Current(user)
+		}
+
+		sess.mu.Lock()
+		if !sess.canView(user) {
+			sess.mu.Unlock()
+			http.Error(w, "private game", http.StatusForbidden)
+			return
+		}
+		sess.touch()
+		sess.mu.Unlock()
+
+		h(w, r, sess)
+	})
+}
+
+// gameURL : chemin d'une partie (ou d'une de ses actions si action != "")
+func gameURL(sess *session, action string) string {
+	if action == "" {
+		return "/games/" + sess.ID
+	}
+	return "/games/" + sess.ID + "/" + action
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request, sess *session) {
+	if r.URL.Path == "/" {
+		u := gameURL(sess, "")
+		if r.URL.RawQuery != "" {
+			u += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, u, http.StatusSeeOther)
+		return
+	}
+
+	sess.mu.Lock()
+	now := time.Now()
+	sess.expire(now)
 	v := viewData{
-		Board:           s.g.Board,
-		Rows:            s.g.Rows,
-		Cols:            s.g.Cols,
-		CurrentPlayer:   s.g.CurrentPlayer,
-		Winner:          s.g.Winner,
-		BoardTemplate:   s.boardTmpl,
-		InvertedGravity: s.g.InvertedGravity,
+		CSRF:            auth.CSRFToken(w, r),
+		GameID:          sess.ID,
+		Board:           copyBoard(sess.g.Board), // rendu hors du verrou
+		Rows:            sess.g.Rows,
+		Cols:            sess.g.Cols,
+		ConnectN:        sess.g.ConnectN,
+		CurrentPlayer:   sess.g.CurrentPlayer,
+		Winner:          sess.g.Winner,
+		WinningCells:    sess.g.WinningCells(),
+		MoveCount:       sess.g.MoveCount,
+		Gen:             sess.gen,
+		Players:         sess.Players,
+		BoardTemplate:   sess.boardTmpl,
+		InvertedGravity: sess.g.InvertedGravity,
+		TimeLeft:        -1,
+		UseBank:         sess.clock.UseBank,
+		Ranked:          sess.ranked,
+		Waiting:         sess.awaitsOpponent(),
+		Spectator:       sess.seatOf(auth.CurrentUser(r)) == 0,
+		CanJoin:         sess.seatOf(auth.CurrentUser(r)) == 0 && sess.Players[1] == "" && sess.privacy != auth.PrivacyPrivate,
+		Spectators:      sess.spectatorCount(),
+		CanUndo:         sess.canUndo(sess.seatOf(auth.CurrentUser(r))),
+		CanRedo:         sess.canRedo(sess.seatOf(auth.CurrentUser(r))),
+		CanAnalyze:      !sess.ranked || s.analyzeRanked,
+		InProgress:      sess.inProgress(),
 	}
-	s.mu.Unlock()
+	if left := sess.clock.Remaining(now); left >= 0 {
+		v.TimeLeft = int((left + time.Second - 1) / time.Second)
+	} else if sess.g.Winner == 0 && sess.clockCfg.Turn > 0 {
+		v.TimeLeft = int(sess.clockCfg.Turn / time.Second) // pendule pas encore lancée
+	}
+	for p := game.P1; p <= game.P2; p++ {
+		v.Bank[p-1] = int(sess.clock.BankLeft(p, now) / time.Second)
+	}
+	if u := sess.inviteURL(); u != "" {
+		v.InviteURL = absURL(r, u)
+	}
+	sess.mu.Unlock()
 
 	// active le mode debug si /?debug=1
 	v.Debug = (r.URL.Query().Get("debug") == "1")
@@ -134,9 +359,9 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, sess *session) {
 	if r.Method != http.MethodPost {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
+		http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
 		return
 	}
 	if err := r.ParseForm(); err != nil {
@@ -149,96 +374,262 @@ func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.mu.Lock()
-	_ = s.g.Drop(col) // ignore si colonne pleine/terminée
-	s.mu.Unlock()
+	sess.mu.Lock()
+	sess.expire(time.Now()) // un coup hors délai n'est plus accepté
+	if sess.g.Winner == 0 && !sess.mayPlay(auth.CurrentUser(r)) {
+		sess.mu.Unlock()
+		http.Error(w, "not your turn", http.StatusForbidden)
+		return
+	}
+	played := sess.g.Drop(col) // ignore si colonne pleine/terminée
+	if played {
+		sess.afterMove()
+	}
+	sess.mu.Unlock()
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	// coup envoyé en fetch par live.js : le plateau est mis à jour par le flux
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"ok": played})
+		return
+	}
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
 }
 
-// handleRandomMove choisit une colonne au hasard (parmi les colonnes non pleines)
-// et y pose le jeton courant. Utilisé quand le timer côté client expire.
-func (s *Server) handleRandomMove(w http.ResponseWriter, r *http.Request) {
+// handleRandomMove force l'application d'un dépassement de temps. Le serveur
+// joue déjà seul à l'expiration du coup ; cette route ne fait rien tant que
+// le temps du joueur courant n'est pas écoulé.
+func (s *Server) handleRandomMove(w http.ResponseWriter, r *http.Request, sess *session) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "invalid method", http.StatusMethodNotAllowed)
 		return
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
 
-	if s.g.Winner != 0 {
+	if sess.g.Winner != 0 {
 		http.Error(w, "game over", http.StatusConflict)
 		return
 	}
-
-	// construire la liste des colonnes disponibles
-	avail := make([]int, 0)
-	for c := 0; c < s.g.Cols; c++ {
-		if s.g.Board[0][c] == game.Empty {
-			avail = append(avail, c)
-		}
-	}
-	if len(avail) == 0 {
-		http.Error(w, "board full", http.StatusConflict)
+	now := time.Now()
+	if !sess.expired(now) {
+		http.Error(w, "turn not expired", http.StatusConflict)
 		return
 	}
-
-	col := avail[rand.Intn(len(avail))]
-	_ = s.g.Drop(col)
+	sess.expire(now)
 
 	// Répondre avec l'état minimal pour le client (ok:true)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
 }
 
-func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
-	s.mu.Lock()
-	rows, cols := s.g.Rows, s.g.Cols
-	s.g.Reset(rows, cols)
-	s.mu.Unlock()
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+// handleState renvoie l'état de la partie en JSON (plateau, tour, pendule).
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request, sess *session) {
+	sess.mu.Lock()
+	now := time.Now()
+	sess.expire(now)
+	st := sess.state(now)
+	sess.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
+
+// state construit la vue JSON de la partie (appelé sous sess.mu). Le
+// résultat est encodé hors du verrou : le plateau est copié.
+func (sess *session) state(now time.Time) stateData {
+	st := stateData{
+		ID:            sess.ID,
+		Board:         copyBoard(sess.g.Board),
+		Rows:          sess.g.Rows,
+		Cols:          sess.g.Cols,
+		ConnectN:      sess.g.ConnectN,
+		CurrentPlayer: sess.g.CurrentPlayer,
+		Winner:        sess.g.Winner,
+		WinningCells:  sess.g.WinningCells(),
+		MoveCount:     sess.g.MoveCount,
+		Gen:           sess.gen,
+		Players:       sess.Players,
+		Spectators:    sess.spectatorCount(),
+	}
+	c := sess.clockState(now)
+	st.TimeLeftMs, st.UseBank, st.BankMs = c.TimeLeftMs, c.UseBank, c.BankMs
+	return st
+}
+
+// errInProgress : reset, nouvelle partie et gravité refusés pendant une
+// partie en ligne ou classée (effacer une défaite ou changer les règles à
+// l'adversaire).
+var errInProgress = errors.New("game in progress: finish it before changing it")
+
+// inProgress : partie en ligne ou classée commencée et pas encore terminée
+// (appelé sous sess.mu). Aucun coup n'y est joué sans adversaire assis
+// (voir awaitsOpponent).
+func (sess *session) inProgress() bool {
+	return (sess.online || sess.ranked) && sess.g.MoveCount > 0 && sess.g.Winner == 0
+}
+
+// seatsVerified : chaque joueur humain de players (bot : place du bot, 0
+// sinon) a un email vérifié. Requêtes BDD : à appeler hors de sess.mu.
+func seatsVerified(ctx context.Context, players [2]string, bot int) bool {
+	for i, name := range players {
+		if name == "" || bot == i+1 {
+			continue
+		}
+		if !auth.EmailVerified(ctx, name) {
+			return false
+		}
+	}
+	return true
 }
 
-// /new?size=small|medium|large
-func (s *Server) handleNew(w http.ResponseWriter, r *http.Request) {
-	size := r.URL.Query().Get("size")
+// handleReset : POST — recommence la partie sur le même plateau.
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request, sess *session) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+		return
+	}
+	sess.mu.Lock()
+	if sess.inProgress() {
+		sess.mu.Unlock()
+		http.Error(w, errInProgress.Error(), http.StatusConflict)
+		return
+	}
+	rows, cols, n := sess.g.Rows, sess.g.Cols, sess.g.ConnectN
+	sess.abandon()
+	sess.g.Reset(rows, cols, n)
+	sess.gen++
+	sess.resetClock()
+	sess.persist() // partie en ligne : nouvelle ligne games
+	sess.botOpens()
+	sess.broadcastState()
+	sess.mu.Unlock()
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
+
+// POST /new : size=small|medium|large[&connect=N][&turn=s&bank=s&inc=s&expiry=random|forfeit][&ranked=1]
+func (s *Server) handleNew(w http.ResponseWriter, r *http.Request, sess *session) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	size := r.Form.Get("size")
 	log.Println("Switch difficulty →", size)
 
-	var rows, cols int
-	var tmpl string
+	rows, cols, tmpl := boardSize(size)
 
+	// connect=N : nombre de jetons à aligner (3 à la plus grande dimension)
+	n := game.DefaultConnectN
+	if v := r.Form.Get("connect"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil || c < 3 || (c > rows && c > cols) {
+			http.Error(w, "invalid connect", http.StatusBadRequest)
+			return
+		}
+		n = c
+	}
+
+	// partie classée : les deux places doivent avoir un email vérifié ;
+	// vérification hors du verrou, sur une copie des places
+	ranked := r.Form.Get("ranked") == "1"
+	sess.mu.Lock()
+	players, bot := sess.Players, sess.bot
+	sess.mu.Unlock()
+	if ranked && !seatsVerified(r.Context(), players, bot) {
+		http.Error(w, errUnverified.Error(), http.StatusForbidden)
+		return
+	}
+
+	sess.mu.Lock()
+	if sess.inProgress() {
+		sess.mu.Unlock()
+		http.Error(w, errInProgress.Error(), http.StatusConflict)
+		return
+	}
+	if ranked && (sess.Players != players || sess.bot != bot) {
+		sess.mu.Unlock()
+		http.Error(w, "players changed, try again", http.StatusConflict)
+		return
+	}
+	cfg, ok := parseClockConfig(r.Form, sess.clockCfg)
+	if !ok {
+		sess.mu.Unlock()
+		http.Error(w, "invalid clock", http.StatusBadRequest)
+		return
+	}
+	sess.abandon()
+	sess.g.Reset(rows, cols, n)
+	sess.gen++
+	sess.boardTmpl = tmpl
+	sess.clockCfg = cfg
+	sess.ranked = ranked
+	sess.resetClock()
+	sess.persist()
+	sess.botOpens()
+	sess.broadcastState()
+	sess.mu.Unlock()
+
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
+
+// absURL : URL complète (schéma + hôte de la requête) d'un chemin, pour les
+// liens à partager.
+func absURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// boardTemplate : template du plateau rows x cols (faux si aucune taille
+// proposée ne correspond).
+func boardTemplate(rows, cols int) (string, bool) {
+	for _, size := range []string{"small", "medium", "large"} {
+		if r, c, tmpl := boardSize(size); r == rows && c == cols {
+			return tmpl, true
+		}
+	}
+	return "", false
+}
+
+// boardSize : dimensions et template du plateau small | medium | large
+// (medium pour toute autre valeur).
+func boardSize(size string) (rows, cols int, tmpl string) {
 	switch size {
 	case "small": // Easy : 6x7
-		rows, cols = 6, 7
-		tmpl = "board_small"
+		return 6, 7, "board_small"
 	case "large": // Hard : 7x8
-		rows, cols = 7, 8
-		tmpl = "board_large"
+		return 7, 8, "board_large"
 	default: // Medium/Normal : 6x9
-		rows, cols = 6, 9
-		tmpl = "board_medium"
+		return 6, 9, "board_medium"
 	}
-
-	s.mu.Lock()
-	s.g.Reset(rows, cols)
-	s.boardTmpl = tmpl
-	s.mu.Unlock()
-
-	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-func (s *Server) handleGravity(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
+// handleGravity : POST inverted=true|false.
+func (s *Server) handleGravity(w http.ResponseWriter, r *http.Request, sess *session) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
 		return
 	}
 
-	inverted := r.URL.Query().Get("inverted") == "true"
+	inverted := r.FormValue("inverted") == "true"
 
-	s.mu.Lock()
-	s.g.InvertedGravity = inverted
-	s.mu.Unlock()
+	sess.mu.Lock()
+	if sess.inProgress() {
+		sess.mu.Unlock()
+		http.Error(w, errInProgress.Error(), http.StatusConflict)
+		return
+	}
+	sess.g.InvertedGravity = inverted
+	sess.gen++
+	sess.broadcastState()
+	sess.mu.Unlock()
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
 }
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+
+	"power4/auth"
+)
+
+// canView indique si user peut regarder la partie (sous sess.mu) : joueurs,
+// tout le monde pour une partie publique, invités pour une partie privée.
+func (sess *session) canView(user string) bool {
+	return sess.seatOf(user) != 0 || sess.privacy != auth.PrivacyPrivate || sess.watchers[user]
+}
+
+// playersOnly réserve une action aux joueurs assis : les spectateurs
+// reçoivent un 403.
+func (s *Server) playersOnly(h gameHandler) gameHandler {
+	return func(w http.ResponseWriter, r *http.Request, sess *session) {
+		user := auth.CurrentUser(r)
+		sess.mu.Lock()
+		seat := sess.seatOf(user)
+		sess.mu.Unlock()
+		if seat == 0 {
+			http.Error(w, "spectators cannot change the game", http.StatusForbidden)
+			return
+		}
+		h(w, r, sess)
+	}
+}
+
+// handleJoin : POST — assoit le visiteur à la place P2 si elle est libre
+// (voir Registry.Join). Seule route qui assoit un joueur dans une partie
+// existante : les GET (page, temps réel, état, analyse) n'assoient personne.
+func (s *Server) handleJoin(w http.ResponseWriter, r *http.Request, sess *session) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+		return
+	}
+	user := auth.CurrentUser(r)
+	if s.games.Join(sess, user) == 0 {
+		sess.mu.Lock()
+		ranked := sess.ranked
+		sess.mu.Unlock()
+		if ranked && !auth.EmailVerified(r.Context(), user) {
+			http.Error(w, errUnverified.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "no free seat in this game", http.StatusConflict)
+		return
+	}
+	http.Redirect(w, r, gameURL(sess, ""), http.StatusSeeOther)
+}
+
+// spectatorCount : nombre de spectateurs connectés (sous sess.mu).
+func (sess *session) spectatorCount() int { return len(sess.spectators) }
+
+// watch compte une connexion temps réel de user s'il n'est pas joueur et
+// renvoie la fonction qui la décompte. Appelé sous sess.mu.
+func (sess *session) watch(user string) (unwatch func()) {
+	if sess.seatOf(user) != 0 {
+		return func() {}
+	}
+	sess.spectators[user]++
+	if sess.spectators[user] == 1 {
+		sess.broadcastSpectators()
+	}
+	return func() {
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		if sess.spectators[user]--; sess.spectators[user] <= 0 {
+			delete(sess.spectators, user)
+			sess.broadcastSpectators()
+		}
+	}
+}
+
+// broadcastSpectators diffuse le nombre de spectateurs (sous sess.mu).
+func (sess *session) broadcastSpectators() {
+	sess.live.publish(spectatorsEvent{Type: "spectators", Count: sess.spectatorCount()})
+}
@@ -0,0 +1,99 @@
+package server
+
+// Flux Server-Sent Events — GET /games/{id}/events[?since=N]
+//
+// Repli pour les clients dont le proxy bloque les WebSocket : mêmes messages
+// JSON que ws.go, envoyés comme événements SSE sans nom ("data: {...}").
+// Les coups et instantanés portent un id "G.N" (génération de l'historique,
+// nombre de coups joués) ; le navigateur le renvoie dans Last-Event-ID à la
+// reconnexion, ce qui vaut since. Un commentaire est envoyé toutes les 30 s pour garder la connexion.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"power4/auth"
+)
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request, sess *session) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("since")
+	}
+	gen, since, err := parseSince(v)
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+
+	sess.mu.Lock()
+	sess.expire(time.Now()) // avant l'abonnement : pas d'événement en double
+	ch := sess.live.subscribe()
+	backlog := sess.resumeEvents(gen, since)
+	unwatch := sess.watch(auth.CurrentUser(r))
+	sess.mu.Unlock()
+	defer unwatch()
+	defer sess.live.unsubscribe(ch)
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // pas de mise en tampon côté nginx
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 2000\n\n")
+
+	for _, e := range backlog {
+		if err := writeSSE(w, e); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok { // abonné trop lent ou partie évincée : le navigateur se reconnecte
+				return
+			}
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSE écrit un événement au format text/event-stream.
+func writeSSE(w http.ResponseWriter, e event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("sse: encode %T: %v", e, err)
+		return nil
+	}
+	switch e := e.(type) {
+	case moveEvent:
+		fmt.Fprintf(w, "id: %d.%d\n", e.Gen, e.MoveNo)
+	case stateEvent:
+		fmt.Fprintf(w, "id: %d.%d\n", e.State.Gen, e.State.MoveCount)
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
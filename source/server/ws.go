@@ -0,0 +1,119 @@
+package server
+
+// Protocole temps réel — GET /games/{id}/ws[?since=G.N]
+//
+// Le serveur envoie des messages JSON texte (voir event dans live.go) ;
+// le client n'envoie rien (hors pong). Types de messages :
+//
+//	{"type":"state","state":{...}}                         instantané complet (même format que /state)
+//	{"type":"move","gen":0,"moveNo":5,"col":3,"row":2,"player":1}
+//	                                                       coup n° moveNo (1 = premier coup)
+//	{"type":"turn","player":2}                             joueur au trait
+//	{"type":"clock","timeLeftMs":9800,"useBank":true,"bankMs":[60000,58000]}
+//	{"type":"winner","winner":1,"cells":[{"r":5,"c":0},…]} fin de partie (1, 2 ou -1 pour un nul)
+//	                                                       et cases des lignes gagnantes
+//	{"type":"spectators","count":3}                        nombre de spectateurs connectés
+//
+// À la connexion, le serveur envoie un "state", ou — si since=G.N (N coups
+// déjà vus par le client dans la génération G de l'historique) est cohérent
+// avec la partie — les coups manqués suivis de "turn"/"clock" ou "winner".
+// Après une annulation, un reset ou un changement de règles, la génération
+// change et un "state" est diffusé : le client doit alors repartir de cet
+// instantané.
+//
+// Keepalive : ping toutes les 30 s ; sans pong dans les 60 s, la connexion
+// est fermée. Un client trop lent à lire est déconnecté et doit se
+// reconnecter avec since.
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"power4/auth"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = 30 * time.Second
+)
+
+// même origine uniquement (vérification par défaut de gorilla)
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request, sess *session) {
+	gen, since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("ws: upgrade failed for game %s: %v", sess.ID, err)
+		return
+	}
+	defer conn.Close()
+
+	// abonnement et rattrapage sous le même verrou : aucun événement perdu
+	sess.mu.Lock()
+	sess.expire(time.Now()) // avant l'abonnement : pas d'événement en double
+	ch := sess.live.subscribe()
+	backlog := sess.resumeEvents(gen, since)
+	unwatch := sess.watch(auth.CurrentUser(r))
+	sess.mu.Unlock()
+	defer unwatch()
+	defer sess.live.unsubscribe(ch)
+
+	// lecture : uniquement pour les pongs et la détection de fermeture
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(e event) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(e) == nil
+	}
+	for _, e := range backlog {
+		if !send(e) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok { // abonné trop lent ou partie évincée
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+					time.Now().Add(wsWriteWait))
+				return
+			}
+			if !send(e) {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
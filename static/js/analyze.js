@@ -0,0 +1,51 @@
+// Conseils de colonnes : interroge /games/{id}/analyze à la demande et
+// résume l'évaluation de chaque colonne jouable et les menaces immédiates.
+document.addEventListener('DOMContentLoaded', () => {
+  const btn = document.getElementById('hint-btn');
+  const hintsEl = document.getElementById('hints');
+  if (!btn || !hintsEl) return;
+
+  // "gagne en 3" : nombre de coups du joueur au trait (demi-coups arrondis)
+  function describe(col) {
+    const moves = Math.ceil(col.plies / 2);
+    switch (col.outcome) {
+      case 'win': return 'gagne en ' + moves;
+      case 'loss': return 'perd en ' + moves;
+      case 'draw': return 'nul';
+      default: return (col.score > 0 ? '+' : '') + col.score;
+    }
+  }
+
+  function show(a) {
+    if (!a.player) {
+      hintsEl.textContent = 'Partie terminée';
+      return;
+    }
+    const parts = a.columns.map(c => 'C' + (c.col + 1) + ' : ' + describe(c));
+    a.threats.forEach((cols, i) => {
+      if (cols.length) {
+        parts.push('⚠ J' + (i + 1) + ' menace ' + cols.map(c => 'C' + (c + 1)).join(', '));
+      }
+    });
+    hintsEl.textContent = parts.join(' · ');
+  }
+
+  btn.addEventListener('click', () => {
+    btn.disabled = true;
+    hintsEl.textContent = 'Analyse…';
+    fetch(btn.dataset.url, { headers: { 'Accept': 'application/json' } })
+      .then(res => {
+        if (!res.ok) throw new Error('analyze failed: ' + res.status);
+        return res.json();
+      })
+      .then(show)
+      .catch(err => {
+        console.error('Erreur analyse:', err);
+        hintsEl.textContent = '';
+      })
+      .finally(() => { btn.disabled = false; });
+  });
+
+  // un coup reçu en direct rend les conseils obsolètes
+  document.addEventListener('live-clock', () => { hintsEl.textContent = ''; });
+});
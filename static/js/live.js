@@ -0,0 +1,186 @@
+// Mises à jour temps réel de la partie (protocole décrit dans
+// source/server/ws.go). WebSocket par défaut, flux SSE (/events) en repli si
+// le WebSocket ne peut pas s'ouvrir. Tant qu'un flux est connecté, les coups
+// sont envoyés en fetch et posés sur le plateau sans recharger la page ;
+// timer.js n'interroge plus /state.
+document.addEventListener('DOMContentLoaded', () => {
+  const board = document.getElementById('live-board');
+  if (!board) return;
+
+  const cols = parseInt(board.dataset.cols, 10);
+  const ranked = board.dataset.ranked === 'true';
+  // Nombre de coups et vainqueur affichés par la page ; gen : génération de
+  // l'historique (change après une annulation, un reset, la gravité…)
+  let shownMoves = parseInt(board.dataset.moveCount, 10) || 0;
+  const shownGen = parseInt(board.dataset.gen, 10) || 0;
+  let shownWinner = parseInt(board.dataset.winner, 10) || 0;
+
+  const turnEl = document.getElementById('turn-indicator');
+  const bankEl = document.getElementById('bank');
+  const timerContainer = document.getElementById('turn-timer');
+
+  // Cases du plateau, dans l'ordre ligne par ligne
+  const holes = board.querySelectorAll('[class^="hole-neon"]');
+
+  // ---- Application des événements ----
+
+  function placeToken(ev) {
+    const hole = holes[ev.row * cols + ev.col];
+    const tpl = document.getElementById('token-tpl-' + ev.player);
+    if (!hole || !tpl) return false;
+    // hole-neon-medium → token-p1-neon-medium, comme dans les templates de plateau
+    const wrap = document.createElement('div');
+    wrap.className = hole.className.replace(/^hole/, 'token-p' + ev.player);
+    wrap.appendChild(tpl.content.cloneNode(true));
+    hole.replaceChildren(wrap);
+    return true;
+  }
+
+  function setMoves(n) {
+    shownMoves = n;
+    board.dataset.moveCount = n;
+    if (timerContainer) timerContainer.dataset.moveCount = n;
+  }
+
+  function onMove(ev) {
+    if (ev.gen !== shownGen) {
+      window.location.reload(); // historique réécrit : on repart de la page serveur
+      return;
+    }
+    if (ev.moveNo <= shownMoves) return; // déjà affiché
+    if (ev.moveNo !== shownMoves + 1 || !placeToken(ev)) {
+      window.location.reload(); // coup manqué : on repart de la page serveur
+      return;
+    }
+    setMoves(ev.moveNo);
+    const undo = document.getElementById('undo-btn');
+    const redo = document.getElementById('redo-btn');
+    if (undo) undo.disabled = ranked;
+    if (redo) redo.disabled = true;
+  }
+
+  function onTurn(ev) {
+    if (turnEl) {
+      turnEl.textContent = 'Tour du joueur ' + ev.player + ' — aligner ' + turnEl.dataset.connectN + ' jetons';
+    }
+  }
+
+  function onWinner(ev) {
+    if (ev.winner === shownWinner) return;
+    shownWinner = ev.winner;
+    if (!turnEl) {
+      window.location.reload();
+      return;
+    }
+    // cases gagnantes lues par l'effet de victoire de layout.gohtml
+    board.dataset.winningCells = JSON.stringify(ev.cells || []);
+    // même texte que layout.gohtml : l'observateur de victoire lance les effets
+    turnEl.textContent = ev.winner === -1 ? '🤝 Match nul ! Plateau plein' : '🎉 Victoire du joueur ' + ev.winner + ' !';
+    if (timerContainer) timerContainer.remove();
+    if (bankEl) bankEl.remove();
+    board.querySelectorAll('button').forEach(b => { b.disabled = true; });
+  }
+
+  function onClock(ev) {
+    if (bankEl && ev.useBank) {
+      bankEl.textContent = 'Réserve J1 : ' + Math.floor(ev.bankMs[0] / 1000) + 's · J2 : ' + Math.floor(ev.bankMs[1] / 1000) + 's';
+    }
+    // timer.js recale son décompte
+    document.dispatchEvent(new CustomEvent('live-clock', { detail: ev }));
+  }
+
+  function onSpectators(ev) {
+    const el = document.getElementById('spectator-count');
+    if (el) el.textContent = ev.count.toString();
+  }
+
+  function handle(ev) {
+    switch (ev.type) {
+      case 'move': onMove(ev); break;
+      case 'turn': onTurn(ev); break;
+      case 'winner': onWinner(ev); break;
+      case 'clock': onClock(ev); break;
+      case 'spectators': onSpectators(ev); break;
+      case 'state':
+        // reset, annulation, arrivée d'un adversaire… : on repart de la page serveur
+        if (ev.state.gen !== shownGen || ev.state.moveCount !== shownMoves || ev.state.winner !== shownWinner ||
+            ev.state.players[1] !== board.dataset.player2) {
+          window.location.reload();
+        }
+        break;
+    }
+  }
+
+  function onMessage(msg) {
+    try {
+      handle(JSON.parse(msg.data));
+    } catch (err) {
+      console.error('Erreur live:', err);
+    }
+  }
+
+  // ---- Transports ----
+
+  let retry = 1000; // délai avant reconnexion WebSocket (ms), doublé à chaque échec
+  const MAX_RETRY = 30000;
+
+  // since : reprise après les coups déjà affichés, dans la même génération
+  function since() {
+    return '?since=' + shownGen + '.' + shownMoves;
+  }
+
+  function connectWS() {
+    const proto = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const ws = new WebSocket(proto + '//' + window.location.host + board.dataset.wsUrl + since());
+    let opened = false;
+
+    ws.onopen = () => {
+      opened = true;
+      retry = 1000;
+      window.liveConnected = true;
+    };
+    ws.onmessage = onMessage;
+    ws.onclose = () => {
+      window.liveConnected = false;
+      if (!opened) {
+        connectSSE(); // WebSocket bloqué (proxy) : repli sur SSE
+        return;
+      }
+      setTimeout(connectWS, retry);
+      retry = Math.min(retry * 2, MAX_RETRY);
+    };
+  }
+
+  function connectSSE() {
+    if (!('EventSource' in window)) return; // timer.js garde le polling
+    // le navigateur se reconnecte seul en renvoyant Last-Event-ID
+    const es = new EventSource(board.dataset.eventsUrl + since());
+    es.onopen = () => { window.liveConnected = true; };
+    es.onmessage = onMessage;
+    es.onerror = () => { window.liveConnected = false; };
+  }
+
+  if ('WebSocket' in window) {
+    connectWS();
+  } else {
+    connectSSE();
+  }
+
+  // ---- Coups joués sans rechargement ----
+
+  const form = board.querySelector('form');
+  if (form) {
+    form.addEventListener('submit', e => {
+      if (!window.liveConnected || !e.submitter) return; // envoi classique
+      e.preventDefault();
+      fetch(form.action, {
+        method: 'POST',
+        headers: {
+          'Accept': 'application/json',
+          'X-CSRF-Token': form.elements.csrf_token ? form.elements.csrf_token.value : '',
+        },
+        body: new URLSearchParams({ col: e.submitter.value }),
+      }).catch(err => console.error('Erreur play:', err));
+    });
+  }
+});
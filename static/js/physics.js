@@ -402,6 +402,14 @@
           inp.name = 'col';
           inp.value = col;
           f.appendChild(inp);
+          const meta = document.querySelector('meta[name="csrf-token"]');
+          if (meta) {
+            const tok = document.createElement('input');
+            tok.type = 'hidden';
+            tok.name = 'csrf_token';
+            tok.value = meta.content;
+            f.appendChild(tok);
+          }
           document.body.appendChild(f);
           f.submit();
         }
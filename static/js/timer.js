@@ -1,117 +1,57 @@
 // Attend que tout le DOM soit chargé avant d’exécuter le script
 document.addEventListener('DOMContentLoaded', () => {
-  // Formulaire principal pour jouer un coup (envoyé sur /play)
-  const playForm = document.querySelector('form[action="/play"]');
   // Élément qui affiche le temps restant (texte du compte à rebours)
   const timeLeftEl = document.getElementById('time-left');
-  // Conteneur du timer (peut servir pour styliser ou masquer le timer)
+  // Conteneur du timer : porte l'URL d'état JSON et le nombre de coups affichés
   const timerContainer = document.getElementById('turn-timer');
-  // Durée maximale par tour en secondes
-  const TIMEOUT = 10;
-  // Identifiant du timer (setInterval)
-  let timer = null;
-  // Nombre de secondes restantes pour le tour en cours
-  let remaining = TIMEOUT;
-
-  // Active ou désactive les contrôles de jeu (boutons du formulaire /play)
-  function disablePlayControls(disabled) {
-    if (!playForm) return; // Si aucun formulaire, rien à faire
-
-    // Sélectionne tous les boutons et submit dans le formulaire
-    const buttons = playForm.querySelectorAll('button, input[type="submit"]');
-    buttons.forEach(b => {
-      b.disabled = disabled; // Active/désactive chaque bouton
-    });
-
-    // Ajoute ou retire une classe CSS pour indiquer que le formulaire est bloqué
-    if (disabled) {
-      playForm.classList.add('blocked-by-timer');
-    } else {
-      playForm.classList.remove('blocked-by-timer');
-    }
-  }
-
-  // Fonction appelée quand le temps est écoulé
-  function onTimeout() {
-    // Bloque tous les contrôles de jeu
-    disablePlayControls(true);
-
-    // Arrête le timer s'il tourne encore
-    if (timer) {
-      clearInterval(timer);
-      timer = null;
+  // Intervalle de synchronisation avec le serveur (ms)
+  const POLL_MS = 2000;
+
+  // Pas de pendule pour cette partie (temps illimité ou partie terminée)
+  if (!timeLeftEl || !timerContainer) return;
+
+  const stateURL = timerContainer.dataset.stateUrl;
+  // Nombre de secondes restantes, tel que rendu par le serveur
+  let remaining = parseInt(timeLeftEl.textContent, 10) || 0;
+
+  // Le serveur est seul maître du temps : ce décompte n'est qu'un affichage.
+  setInterval(() => {
+    if (remaining > 0) remaining -= 1;
+    timeLeftEl.textContent = remaining.toString();
+  }, 1000);
+
+  // Pendule poussée par live.js (WebSocket)
+  document.addEventListener('live-clock', e => {
+    if (e.detail.timeLeftMs >= 0) {
+      remaining = Math.ceil(e.detail.timeLeftMs / 1000);
+      timeLeftEl.textContent = remaining.toString();
     }
+  });
 
-    // Met à jour l'affichage du temps restant à 0
-    if (timeLeftEl) timeLeftEl.textContent = '0';
-
-    // Envoie une requête au serveur pour jouer un coup aléatoire
-    fetch('/random_move', { method: 'POST' })
+  // Interroge l'état de la partie ; si un coup a été joué (par l'adversaire ou
+  // par le serveur à l'expiration du temps), on recharge la page.
+  function sync() {
+    if (window.liveConnected) return; // live.js reçoit déjà les événements
+    fetch(stateURL)
       .then(res => {
-        if (!res.ok) throw new Error('random move failed'); // Erreur si réponse non OK
-        // Recharge la page pour afficher le nouveau plateau après le coup aléatoire
-        window.location.reload();
+        if (!res.ok) throw new Error('state failed'); // Erreur si réponse non OK
+        return res.json();
       })
-      .catch(err => {
-        console.error('Erreur random_move:', err);
-        // En cas d'erreur, on laisse l'interface bloquée pour éviter un état incohérent
-      });
-  }
-
-  // Lance ou relance le compte à rebours
-  function startTimer() {
-    if (!timeLeftEl) return; // Si pas d'affichage de temps, on ne fait rien
-
-    // Réinitialise le temps restant
-    remaining = TIMEOUT;
-    timeLeftEl.textContent = remaining.toString(); // Affiche la valeur initiale
-
-    // Réactive les contrôles de jeu au début du tour
-    disablePlayControls(false);
-
-    // Supprime un ancien timer s'il existe
-    if (timer) clearInterval(timer);
-
-    // Crée un nouveau timer qui s'exécute toutes les secondes
-    timer = setInterval(() => {
-      remaining -= 1; // Décrémente le temps restant
-      timeLeftEl.textContent = remaining.toString(); // Met à jour l'affichage
-
-      // Si le temps est écoulé, on arrête le timer et on déclenche onTimeout
-      if (remaining <= 0) {
-        clearInterval(timer);
-        timer = null;
-        onTimeout();
-      }
-    }, 1000);
-  }
-
-  // Si le formulaire de jeu existe, on met en place le timer
-  if (playForm) {
-    // Démarre le timer dès le chargement de la page
-    startTimer();
-
-    // Quand l'utilisateur soumet un coup, on arrête le timer
-    playForm.addEventListener('submit', () => {
-      if (timer) {
-        clearInterval(timer);
-        timer = null;
-      }
-      // Petit délai pour laisser la requête partir avant que la page ne se recharge
-      setTimeout(() => {
-        // Au retour (après redirection), le script se relancera et redémarrera le timer
-      }, 200);
-    });
-
-    // Si l'utilisateur change de taille de plateau (nouvelle partie), on arrête aussi le timer
-    const newForm = document.querySelector('form[action="/new"]');
-    if (newForm) {
-      newForm.addEventListener('submit', () => {
-        if (timer) {
-          clearInterval(timer);
-          timer = null;
+      .then(st => {
+        // live.js met à jour le nombre de coups affichés sans recharger
+        const shownMoves = parseInt(timerContainer.dataset.moveCount, 10);
+        if (st.moveCount !== shownMoves || st.winner !== 0) {
+          window.location.reload();
+          return;
         }
-      });
-    }
+        // Recale l'affichage sur l'horloge du serveur
+        if (st.timeLeftMs >= 0) {
+          remaining = Math.ceil(st.timeLeftMs / 1000);
+          timeLeftEl.textContent = remaining.toString();
+        }
+      })
+      .catch(err => console.error('Erreur state:', err));
   }
+
+  setInterval(sync, POLL_MS);
 });